@@ -0,0 +1,218 @@
+// Package archive reads and writes the zip and tar.gz bundles that carry
+// Synapse artifacts between the packaging and deploy steps.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ReadFile reads a package file from the local filesystem.
+func ReadFile(filePath string) ([]byte, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local package %s: %v", filePath, err)
+	}
+	return data, nil
+}
+
+// Open reads a zip or tar.gz package, picking the format from its extension.
+func Open(filePath string) (map[string][]byte, error) {
+	data, err := ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if IsTarGz(filePath) {
+		return UntarGz(data)
+	}
+	return Unzip(data)
+}
+
+// IsTar reports whether a package path names an uncompressed tar archive.
+func IsTar(filePath string) bool {
+	return strings.HasSuffix(filePath, ".tar")
+}
+
+// IsTarGz reports whether a package path names a tar.gz archive.
+func IsTarGz(filePath string) bool {
+	return strings.HasSuffix(filePath, ".tar.gz") || strings.HasSuffix(filePath, ".tgz")
+}
+
+// Unzip extracts every file of a zip archive into a map keyed by entry name.
+func Unzip(data []byte) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %v", err)
+	}
+
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		content, err := readZipEntry(f)
+		if err != nil {
+			return nil, err
+		}
+		files[f.Name] = content
+	}
+	return files, nil
+}
+
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s in zip: %v", f.Name, err)
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s in zip: %v", f.Name, err)
+	}
+	return content, nil
+}
+
+// UntarGz extracts the .json files of a tar.gz archive into a map keyed by
+// entry name.
+func UntarGz(data []byte) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GZIP reader: %v", err)
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar entry: %v", err)
+		}
+
+		// Only regular .json files carry artifact definitions
+		if header.Typeflag != tar.TypeReg || filepath.Ext(header.Name) != ".json" {
+			continue
+		}
+
+		content, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s from tar.gz: %v", header.Name, err)
+		}
+		files[strings.TrimPrefix(header.Name, "./")] = content
+	}
+
+	return files, nil
+}
+
+// Untar extracts every regular file of an uncompressed tar archive, such as
+// a docker save archive, into a map keyed by entry name.
+func Untar(data []byte) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	tarReader := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar entry: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s from tar: %v", header.Name, err)
+		}
+		files[strings.TrimPrefix(header.Name, "./")] = content
+	}
+
+	return files, nil
+}
+
+// Zip writes files into a zip archive, ordered by name so identical inputs
+// produce identical archives.
+func Zip(files map[string][]byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(buf)
+
+	for _, name := range SortedNames(files) {
+		w, err := zipWriter.Create(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zip entry: %v", err)
+		}
+
+		if _, err := w.Write(files[name]); err != nil {
+			return nil, fmt.Errorf("failed to write to zip: %v", err)
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close zip: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Extract writes files below dir, creating folders as needed.
+func Extract(files map[string][]byte, dir string) error {
+	for _, name := range SortedNames(files) {
+		dest := filepath.Join(dir, filepath.FromSlash(name))
+		if !strings.HasPrefix(dest, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("refusing to extract %s outside %s", name, dir)
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(dest, files[name], 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", dest, err)
+		}
+	}
+	return nil
+}
+
+// SortedNames returns the file names of a package in lexical order.
+func SortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// JSONFilesInDirectory lists every .json file below root.
+func JSONFilesInDirectory(root string) ([]string, error) {
+	var filePaths []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() && filepath.Ext(path) == ".json" {
+			filePaths = append(filePaths, path)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in directory: %v", err)
+	}
+
+	return filePaths, nil
+}
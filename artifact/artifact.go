@@ -0,0 +1,328 @@
+// Package artifact describes the Synapse workspace artifacts handled by
+// synapsectl and the folder layout Synapse Studio uses for them in Git.
+package artifact
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Artifact types, named after the folders Synapse Studio writes to Git.
+const (
+	ManagedVirtualNetwork = "managedVirtualNetwork"
+	IntegrationRuntime    = "integrationRuntime"
+	Credential            = "credential"
+	LinkedService         = "linkedService"
+	Dataset               = "dataset"
+	DataFlow              = "dataflow"
+	Notebook              = "notebook"
+	SQLScript             = "sqlscript"
+	KQLScript             = "kqlscript"
+	SparkConfiguration    = "sparkConfiguration"
+	SparkJobDefinition    = "sparkJobDefinition"
+	Pipeline              = "pipeline"
+	Trigger               = "trigger"
+)
+
+// Types lists every supported artifact type in the order they are deployed.
+var Types = []string{
+	ManagedVirtualNetwork,
+	IntegrationRuntime,
+	Credential,
+	LinkedService,
+	Dataset,
+	DataFlow,
+	Notebook,
+	SQLScript,
+	KQLScript,
+	SparkConfiguration,
+	SparkJobDefinition,
+	Pipeline,
+	Trigger,
+}
+
+// readOnlyTypes are the artifact types the workspace data plane cannot
+// create, update or delete.
+var readOnlyTypes = map[string]bool{
+	ManagedVirtualNetwork: true,
+	IntegrationRuntime:    true,
+	Credential:            true,
+}
+
+// IsReadOnly reports whether artifacts of a type cannot be written to a
+// workspace. Integration runtimes, managed virtual networks and credentials
+// are managed through Azure Resource Manager; packages keep them so
+// references to them resolve, but they are never published or deleted.
+func IsReadOnly(artifactType string) bool {
+	return readOnlyTypes[artifactType]
+}
+
+// folderAliases maps the folder names of exports made with the az CLI, which
+// files artifacts under its command group names such as linked-service/, to
+// artifact types.
+var folderAliases = map[string]string{
+	"linked-service":       LinkedService,
+	"data-flow":            DataFlow,
+	"sql-script":           SQLScript,
+	"kql-script":           KQLScript,
+	"spark-job-definition": SparkJobDefinition,
+	"integration-runtime":  IntegrationRuntime,
+}
+
+// TypeFromFolder maps a Git folder name, or the name the az CLI uses for the
+// type, to its artifact type.
+func TypeFromFolder(folder string) (string, error) {
+	for _, t := range Types {
+		if t == folder {
+			return t, nil
+		}
+	}
+	if t, ok := folderAliases[folder]; ok {
+		return t, nil
+	}
+	return "", fmt.Errorf("unsupported folder: %s", folder)
+}
+
+// TypeFromPath infers the artifact type from the folder holding a package file.
+func TypeFromPath(p string) (string, error) {
+	return TypeFromFolder(path.Base(path.Dir(toSlash(p))))
+}
+
+// NameFromPath returns the artifact name for a package file, which is its
+// file name without the .json extension.
+func NameFromPath(p string) string {
+	return strings.TrimSuffix(path.Base(toSlash(p)), ".json")
+}
+
+// IsJSON reports whether a package file holds an artifact definition.
+func IsJSON(p string) bool {
+	return strings.HasSuffix(p, ".json")
+}
+
+// InLayout reports whether a slash-separated path relative to the package
+// root is an artifact file of the Synapse Git layout: <type>/<name>.json
+// directly below the folder of a known artifact type.
+func InLayout(rel string) bool {
+	dir, file := path.Split(rel)
+	if !IsJSON(file) || strings.Count(rel, "/") != 1 {
+		return false
+	}
+	_, err := TypeFromFolder(strings.TrimSuffix(dir, "/"))
+	return err == nil
+}
+
+// SkipReason explains why the JSON file at rel, a slash-separated path
+// relative to the package root, is not InLayout.
+func SkipReason(rel string) string {
+	switch strings.Count(rel, "/") {
+	case 0:
+		return "not in an artifact type folder"
+	case 1:
+		return fmt.Sprintf("%s is not a supported artifact type", path.Dir(rel))
+	default:
+		return "nested below " + rel[:strings.Index(rel, "/")+1] + ", not <type>/<name>.json at the package root"
+	}
+}
+
+func toSlash(p string) string {
+	return strings.ReplaceAll(p, "\\", "/")
+}
+
+// Artifact is a single artifact definition read from a package.
+type Artifact struct {
+	// Type is the artifact type, such as notebook or linkedService.
+	Type string
+	// Name is the artifact name as known to the workspace.
+	Name string
+	// Folder is the Synapse Studio folder the artifact is filed under, if any.
+	Folder string
+	// Content is the raw JSON definition.
+	Content json.RawMessage
+	// Origin is the path or URL the artifact was read from.
+	Origin string
+}
+
+// New builds an artifact from a package file. The type comes from the folder
+// holding the file; the name and Studio folder come from the definition, with
+// the file name as fallback for the name.
+func New(origin string, content []byte) (Artifact, error) {
+	if !IsJSON(origin) {
+		return Artifact{}, fmt.Errorf("%s is not a JSON file", origin)
+	}
+
+	artifactType, err := TypeFromPath(origin)
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	a, err := FromDefinition(artifactType, content, origin)
+	if err != nil {
+		return Artifact{}, err
+	}
+	if a.Name == "" {
+		a.Name = NameFromPath(origin)
+	}
+	return a, nil
+}
+
+// FromDefinition builds an artifact of a known type from its JSON
+// definition, taking the name and Studio folder from the definition.
+func FromDefinition(artifactType string, content []byte, origin string) (Artifact, error) {
+	var def struct {
+		Name       string `json:"name"`
+		Properties struct {
+			Folder struct {
+				Name string `json:"name"`
+			} `json:"folder"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(content, &def); err != nil {
+		return Artifact{}, fmt.Errorf("failed to parse %s: %v", origin, err)
+	}
+
+	return Artifact{
+		Type:    artifactType,
+		Name:    def.Name,
+		Folder:  def.Properties.Folder.Name,
+		Content: content,
+		Origin:  origin,
+	}, nil
+}
+
+// Key identifies an artifact within a package as type/name.
+func (a Artifact) Key() string {
+	return a.Type + "/" + a.Name
+}
+
+// Path is the file path of the artifact in the Synapse Git layout.
+func (a Artifact) Path() string {
+	return a.Type + "/" + a.Name + ".json"
+}
+
+// FromFiles builds artifacts from package files keyed by their path
+// relative to the package root. JSON files that are not InLayout, such as a
+// notebook/ folder nested deeper in the tree, are left out and returned as
+// skipped; other files are ignored. The origin of each artifact is prefix
+// joined with the file name.
+func FromFiles(files map[string][]byte, prefix string) (artifacts []Artifact, skipped []string, err error) {
+	paths := make(map[string]string)
+	for fileName, content := range files {
+		if !InLayout(fileName) {
+			if IsJSON(fileName) {
+				skipped = append(skipped, fileName)
+			}
+			continue
+		}
+
+		a, err := New(fileName, content)
+		if err != nil {
+			return nil, nil, err
+		}
+		if other, ok := paths[a.Key()]; ok {
+			first, second := other, fileName
+			if second < first {
+				first, second = second, first
+			}
+			return nil, nil, fmt.Errorf("%s and %s both define %s", first, second, a.Key())
+		}
+		paths[a.Key()] = fileName
+		if prefix != "" {
+			a.Origin = prefix + "/" + fileName
+		}
+		artifacts = append(artifacts, a)
+	}
+
+	Sort(artifacts)
+	sort.Strings(skipped)
+	return artifacts, skipped, nil
+}
+
+// ToFiles lays artifacts out as package files in the Synapse Git layout.
+func ToFiles(artifacts []Artifact) map[string][]byte {
+	files := make(map[string][]byte, len(artifacts))
+	for _, a := range artifacts {
+		files[a.Path()] = a.Content
+	}
+	return files
+}
+
+// Sort orders artifacts by deploy order of their type, then by name.
+func Sort(artifacts []Artifact) {
+	rank := make(map[string]int, len(Types))
+	for i, t := range Types {
+		rank[t] = i
+	}
+
+	sort.SliceStable(artifacts, func(i, j int) bool {
+		if artifacts[i].Type != artifacts[j].Type {
+			return rank[artifacts[i].Type] < rank[artifacts[j].Type]
+		}
+		return artifacts[i].Name < artifacts[j].Name
+	})
+}
+
+// referenceTypes maps reference types whose artifact type does not follow
+// the usual "<Type>Reference" naming.
+var referenceTypes = map[string]string{
+	"SqlScriptReference": SQLScript,
+	"KqlScriptReference": KQLScript,
+	"DataFlowReference":  DataFlow,
+}
+
+// TypeFromReference maps the type of a reference object, such as
+// NotebookReference, to the artifact type it points at. Types of workspace
+// resources outside the Git layout map the same way, so BigDataPoolReference
+// becomes bigDataPool.
+func TypeFromReference(refType string) (string, bool) {
+	if t, ok := referenceTypes[refType]; ok {
+		return t, true
+	}
+
+	base := strings.TrimSuffix(refType, "Reference")
+	if base == refType || base == "" {
+		return "", false
+	}
+	return strings.ToLower(base[:1]) + base[1:], true
+}
+
+// workspaceDefaultMarker follows the workspace name in the names of the
+// linked services Synapse creates for each workspace, such as
+// <workspace>-WorkspaceDefaultStorage for its primary storage account and
+// <workspace>-WorkspaceDefaultSqlServer for its built-in SQL pool.
+const workspaceDefaultMarker = "-WorkspaceDefault"
+
+// workspaceDefaultSuffix returns the part of the name of a workspace default
+// linked service that follows the workspace name, such as
+// -WorkspaceDefaultStorage.
+func workspaceDefaultSuffix(a Artifact) (string, bool) {
+	if a.Type != LinkedService {
+		return "", false
+	}
+	i := strings.LastIndex(a.Name, workspaceDefaultMarker)
+	if i <= 0 {
+		return "", false
+	}
+	return a.Name[i:], true
+}
+
+// IsWorkspaceDefault reports whether a is one of the linked services Synapse
+// creates for each workspace, named <workspace>-WorkspaceDefault*. They
+// belong to the workspace, not to a package.
+func IsWorkspaceDefault(a Artifact) bool {
+	_, ok := workspaceDefaultSuffix(a)
+	return ok
+}
+
+// WorkspaceDefaultIn returns the name the workspace default linked service a
+// has in the named workspace, for example ws2-WorkspaceDefaultStorage for
+// ws1-WorkspaceDefaultStorage. It returns false when a is not one.
+func WorkspaceDefaultIn(a Artifact, workspace string) (string, bool) {
+	suffix, ok := workspaceDefaultSuffix(a)
+	if !ok {
+		return "", false
+	}
+	return workspace + suffix, true
+}
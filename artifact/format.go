@@ -0,0 +1,118 @@
+package artifact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// serviceFields are maintained by Synapse and left out of committed
+// definitions; serviceProperties likewise within properties.
+var (
+	serviceFields     = map[string]bool{"id": true, "etag": true}
+	serviceProperties = map[string]bool{"lastPublishTime": true}
+)
+
+// Format lays out a definition the way Synapse Studio commits it to Git:
+// without id, etag and properties.lastPublishTime, fields otherwise in their
+// original order, indented with tabs and without a trailing newline.
+func Format(content []byte) ([]byte, error) {
+	members, err := objectMembers(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for _, m := range members {
+		if serviceFields[m.name] {
+			continue
+		}
+		value := m.value
+		if m.name == "properties" {
+			if value, err = dropMembers(value, serviceProperties); err != nil {
+				return nil, err
+			}
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		writeMember(&buf, m.name, value)
+	}
+	buf.WriteByte('}')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "\t"); err != nil {
+		return nil, fmt.Errorf("failed to indent definition: %v", err)
+	}
+	return out.Bytes(), nil
+}
+
+type member struct {
+	name  string
+	value json.RawMessage
+}
+
+// objectMembers returns the members of a JSON object in document order.
+func objectMembers(content []byte) ([]member, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("invalid definition: %v", err)
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("definition is not a JSON object")
+	}
+
+	var members []member
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid definition: %v", err)
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, fmt.Errorf("invalid definition: %v", err)
+		}
+		members = append(members, member{name: key.(string), value: value})
+	}
+	return members, nil
+}
+
+// dropMembers removes the named members of value when it is an object.
+func dropMembers(value json.RawMessage, names map[string]bool) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(value)) == 0 || bytes.TrimSpace(value)[0] != '{' {
+		return value, nil
+	}
+	members, err := objectMembers(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for _, m := range members {
+		if names[m.name] {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		writeMember(&buf, m.name, m.value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func writeMember(buf *bytes.Buffer, name string, value json.RawMessage) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(name)
+	buf.Truncate(buf.Len() - 1) // Encode appends a newline
+	buf.WriteByte(':')
+	buf.Write(value)
+}
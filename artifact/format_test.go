@@ -0,0 +1,58 @@
+package artifact
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "service fields",
+			content: `{"id":"/notebooks/nb1","name":"nb1","etag":"\"0a00\"","type":"Microsoft.Synapse/workspaces/notebooks","properties":{"lastPublishTime":"2026-10-16T09:30:00Z","folder":{"name":"etl"}}}`,
+			want:    "{\n\t\"name\": \"nb1\",\n\t\"type\": \"Microsoft.Synapse/workspaces/notebooks\",\n\t\"properties\": {\n\t\t\"folder\": {\n\t\t\t\"name\": \"etl\"\n\t\t}\n\t}\n}",
+		},
+		{
+			name:    "field order kept",
+			content: `{"properties":{"b":1,"a":2},"name":"p1"}`,
+			want:    "{\n\t\"properties\": {\n\t\t\"b\": 1,\n\t\t\"a\": 2\n\t},\n\t\"name\": \"p1\"\n}",
+		},
+		{
+			name:    "only top-level id and etag",
+			content: `{"name":"ds1","properties":{"id":"x","typeProperties":{"etag":"y","lastPublishTime":"z"}}}`,
+			want:    "{\n\t\"name\": \"ds1\",\n\t\"properties\": {\n\t\t\"id\": \"x\",\n\t\t\"typeProperties\": {\n\t\t\t\"etag\": \"y\",\n\t\t\t\"lastPublishTime\": \"z\"\n\t\t}\n\t}\n}",
+		},
+		{
+			name:    "html kept",
+			content: `{"name":"q","properties":{"query":"SELECT * FROM t WHERE a < 1 && b > 2"}}`,
+			want:    "{\n\t\"name\": \"q\",\n\t\"properties\": {\n\t\t\"query\": \"SELECT * FROM t WHERE a < 1 && b > 2\"\n\t}\n}",
+		},
+		{
+			name:    "properties not an object",
+			content: `{"name":"x","properties":null}`,
+			want:    "{\n\t\"name\": \"x\",\n\t\"properties\": null\n}",
+		},
+		{name: "array", content: `[{"name":"x"}]`, wantErr: true},
+		{name: "truncated", content: `{"name":"x",`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format([]byte(tt.content))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Format = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Format =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/deploy"
+	"github.com/utsavudhungana/artifactsrepo/graph"
+	"github.com/utsavudhungana/artifactsrepo/prune"
+	"github.com/utsavudhungana/artifactsrepo/target"
+)
+
+func runDeploy(args []string) error {
+	fs := flag.NewFlagSet("deploy", flag.ExitOnError)
+	var pkg packageFlags
+	var ws workspaceFlags
+	var tgt targetFlags
+	var prn pruneFlags
+	var params parameterFlags
+	var opts deploy.Options
+	pkg.register(fs)
+	pkg.registerVerify(fs)
+	ws.register(fs)
+	tgt.register(fs)
+	prn.register(fs)
+	params.register(fs)
+	fs.IntVar(&opts.Parallelism, "parallelism", 4, "most artifacts published at once within a dependency level")
+	fs.BoolVar(&opts.ContinueOnError, "continue-on-error", false, "keep publishing after a failure, skipping artifacts that depend on it")
+	fs.Parse(args)
+
+	env, err := ws.resolve()
+	if err != nil {
+		return err
+	}
+	pkg.registry.azure = &ws.creds
+	prn.exclude = append(stringList(env.Exclude), prn.exclude...)
+	params.files = append(stringList(env.Parameters), params.files...)
+
+	filter, err := prn.filter()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	artifacts, err := pkg.artifacts(ctx)
+	if err != nil {
+		return err
+	}
+	if pkg.manifest != nil {
+		fmt.Printf("Verified package manifest: %s\n", pkg.manifest)
+	}
+	if err := params.apply(ctx, artifacts, ws.creds); err != nil {
+		return err
+	}
+
+	g, err := graph.Build(artifacts)
+	if err != nil {
+		return err
+	}
+
+	dest, err := tgt.open(ctx, &ws)
+	if err != nil {
+		return err
+	}
+	if z, ok := dest.(*target.Zip); ok {
+		z.Source = pkg.provenance()
+	}
+
+	if err := deploy.Publish(ctx, dest, g, opts); err != nil {
+		target.Abort(dest)
+		return err
+	}
+
+	if prn.enabled {
+		if err := pruneTarget(ctx, dest, artifacts, filter); err != nil {
+			target.Abort(dest)
+			return err
+		}
+	}
+	if err := dest.Close(); err != nil {
+		return err
+	}
+
+	fmt.Println("Artifacts published successfully.")
+	return nil
+}
+
+// pruneTarget deletes the target artifacts that are missing from the
+// package. It runs after publishing, so artifacts that referenced a removed
+// artifact have already been updated.
+func pruneTarget(ctx context.Context, dest target.ArtifactTarget, artifacts []artifact.Artifact, filter prune.Filter) error {
+	reader, canRead := dest.(target.Reader)
+	deleter, canDelete := dest.(target.Deleter)
+	if !canRead || !canDelete {
+		return fmt.Errorf("target %s does not support prune", dest)
+	}
+
+	orphans, err := prune.Orphans(ctx, artifacts, reader, filter)
+	if err != nil {
+		return err
+	}
+	return prune.Delete(ctx, deleter, orphans)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/utsavudhungana/artifactsrepo/registry"
+)
+
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	from := fs.String("from", "", "older package reference, in the layers layout")
+	to := fs.String("to", "", "newer package reference, in the layers layout")
+	var reg registryFlags
+	reg.register(fs)
+	fs.Parse(args)
+
+	if *from == "" || *to == "" {
+		return fmt.Errorf("-from and -to are required")
+	}
+
+	cred, err := reg.provider()
+	if err != nil {
+		return err
+	}
+	fromTarget, fromRef, err := registry.Open(*from, cred)
+	if err != nil {
+		return err
+	}
+	toTarget, toRef, err := registry.Open(*to, cred)
+	if err != nil {
+		return err
+	}
+
+	changes, err := registry.Diff(context.Background(), fromTarget, fromRef, toTarget, toRef)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, c := range changes {
+		fmt.Printf("%s %s\n", c.Action, c.Path)
+		counts[c.Action]++
+	}
+	fmt.Printf("\nDiff: %d added, %d changed, %d removed.\n", counts["+"], counts["~"], counts["-"])
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/export"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+	"github.com/utsavudhungana/artifactsrepo/packager"
+	"github.com/utsavudhungana/artifactsrepo/registry"
+	"github.com/utsavudhungana/artifactsrepo/target"
+)
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	var ws workspaceFlags
+	ws.register(fs)
+	types := fs.String("type", "", "comma-separated artifact types to export, for example notebook,sqlscript (default all)")
+	out := fs.String("out", "artifacts", "directory, or .zip package, to write the artifact definitions to")
+	push := fs.String("push", "", "registry repository to push the exported package to instead of writing -out")
+	layout := fs.String("layout", registry.LayoutZip, "package layout for -push: zip (one layer) or layers (one layer per artifact)")
+	var reg registryFlags
+	reg.register(fs)
+	fs.Parse(args)
+
+	if _, err := ws.resolve(); err != nil {
+		return err
+	}
+	reg.azure = &ws.creds
+
+	var typeList []string
+	if *types != "" {
+		typeList = strings.Split(*types, ",")
+	}
+
+	ctx := context.Background()
+	client, err := ws.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	artifacts, err := export.Workspace(ctx, &target.REST{Client: client}, typeList)
+	if err != nil {
+		return err
+	}
+
+	if *push != "" {
+		data, err := packager.Build(artifacts, manifest.Source{Repository: client.Endpoint})
+		if err != nil {
+			return err
+		}
+		cred, err := reg.provider()
+		if err != nil {
+			return err
+		}
+		desc, err := registry.Push(ctx, *push, data, *layout, cred)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Exported %d artifacts to %s with digest %s\n", len(artifacts), *push, desc.Digest)
+		return nil
+	}
+
+	dest := target.OpenLocal(*out)
+	for _, a := range artifacts {
+		if err := dest.Publish(ctx, a); err != nil {
+			target.Abort(dest)
+			return err
+		}
+	}
+	if err := dest.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d artifacts to %s\n", len(artifacts), *out)
+	return nil
+}
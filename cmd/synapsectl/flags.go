@@ -0,0 +1,525 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/config"
+	"github.com/utsavudhungana/artifactsrepo/gitlab"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+	"github.com/utsavudhungana/artifactsrepo/prune"
+	"github.com/utsavudhungana/artifactsrepo/registry"
+	"github.com/utsavudhungana/artifactsrepo/secret"
+	"github.com/utsavudhungana/artifactsrepo/signature"
+	"github.com/utsavudhungana/artifactsrepo/source"
+	"github.com/utsavudhungana/artifactsrepo/synapse"
+	"github.com/utsavudhungana/artifactsrepo/target"
+	"github.com/utsavudhungana/artifactsrepo/transform"
+)
+
+// packageFlags selects where an artifact package is read from.
+type packageFlags struct {
+	path            string
+	registryRef     string
+	gitlabProject   int
+	gitlabRef       string
+	gitlabURL       string
+	gitlabCABundle  string
+	gitlabRoot      string
+	allowUnverified bool
+	strict          bool
+	verifyKey       string
+	registry        registryFlags
+
+	// manifest is the verified manifest of the loaded package, if any.
+	manifest *manifest.Manifest
+}
+
+func (p *packageFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.path, "package", "", "path to a local .zip, .tar.gz or docker save .tar package, or a directory in the Synapse Git layout")
+	fs.StringVar(&p.registryRef, "ref", "", "registry reference of a package (registry/repository:tag or @digest, or oci:path:tag)")
+	fs.IntVar(&p.gitlabProject, "gitlab-project", 0, "ID of the GitLab project holding the artifacts")
+	fs.StringVar(&p.gitlabRef, "gitlab-ref", "", "GitLab branch name or commit hash")
+	fs.StringVar(&p.gitlabURL, "gitlab-url", "", "GitLab instance URL (default $GITLAB_URL, then $CI_SERVER_URL, then "+gitlab.DefaultURL+")")
+	fs.StringVar(&p.gitlabCABundle, "gitlab-ca-bundle", "", "PEM file of extra CA certificates for the GitLab instance (default $GITLAB_CA_BUNDLE, then $CI_SERVER_TLS_CA_FILE)")
+	fs.StringVar(&p.gitlabRoot, "gitlab-root", "", "repository directory holding the artifact folders, for example synapse/ (default the repository root)")
+	fs.BoolVar(&p.allowUnverified, "allow-unverified", false, "read zip, tar.gz and registry packages that have no manifest, with a warning")
+	fs.BoolVar(&p.strict, "strict", false, "refuse packages holding JSON files outside the Synapse Git layout instead of skipping them")
+	p.registry.register(fs)
+}
+
+// registerVerify adds the -verify-key flag.
+func (p *packageFlags) registerVerify(fs *flag.FlagSet) {
+	fs.StringVar(&p.verifyKey, "verify-key", "", "PEM public key; refuse packages without a valid signature by it")
+}
+
+// source returns the artifact source for the selected location.
+func (p *packageFlags) source() (source.ArtifactSource, error) {
+	switch {
+	case p.path != "":
+		return source.Open(p.path)
+	case p.registryRef != "":
+		cred, err := p.registry.provider()
+		if err != nil {
+			return nil, err
+		}
+		return &source.OCI{Reference: p.registryRef, Credentials: cred}, nil
+	case p.gitlabProject != 0:
+		return p.gitLabSource()
+	default:
+		return nil, fmt.Errorf("one of -package, -ref or -gitlab-project is required")
+	}
+}
+
+// artifacts loads the artifacts of the selected source. JSON files the
+// source leaves out are reported, or refused with -strict. A package
+// manifest is verified by the source. Packages are refused without one
+// unless -allow-unverified is set, since their artifacts could have been
+// changed after the build; directories, GitLab refs and push_dir.sh images
+// never carry a manifest and are read as they are.
+func (p *packageFlags) artifacts(ctx context.Context) ([]artifact.Artifact, error) {
+	src, err := p.source()
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts, err := src.Artifacts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load artifacts from %s: %v", src, err)
+	}
+	if err := p.checkSkipped(src); err != nil {
+		return nil, err
+	}
+
+	if v, ok := src.(source.Verified); ok {
+		p.manifest = v.Manifest()
+	}
+	if p.manifest == nil && p.verifyKey != "" {
+		return nil, fmt.Errorf("%s has no manifest", src)
+	}
+	if pkg, ok := src.(source.Packaged); p.manifest == nil && ok && pkg.IsPackage() {
+		if !p.allowUnverified {
+			return nil, fmt.Errorf("%s has no %s, so its artifacts cannot be checked for tampering; build it with synapsectl package, or pass -allow-unverified to read it anyway", src, manifest.FileName)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s has no %s, so its artifacts cannot be checked for tampering\n", src, manifest.FileName)
+	}
+	if p.verifyKey != "" {
+		if err := p.verify(ctx, src); err != nil {
+			return nil, err
+		}
+	}
+	return artifacts, nil
+}
+
+// checkSkipped reports the JSON files src left out because they are not in
+// the Synapse Git layout, failing with -strict.
+func (p *packageFlags) checkSkipped(src source.ArtifactSource) error {
+	s, ok := src.(source.Skipping)
+	if !ok || len(s.Skipped()) == 0 {
+		return nil
+	}
+
+	var reasons []string
+	for _, rel := range s.Skipped() {
+		reasons = append(reasons, fmt.Sprintf("%s (%s)", rel, artifact.SkipReason(rel)))
+	}
+	if p.strict {
+		return fmt.Errorf("%s holds files outside the Synapse Git layout: %s", src, strings.Join(reasons, ", "))
+	}
+	for _, reason := range reasons {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %s in %s; -strict refuses such packages\n", reason, src)
+	}
+	return nil
+}
+
+// verify checks that a signature of the loaded package matches -verify-key.
+// The manifest the signature covers has been checked against every artifact
+// already.
+func (p *packageFlags) verify(ctx context.Context, src source.ArtifactSource) error {
+	key, err := signature.LoadPublicKey(p.verifyKey)
+	if err != nil {
+		return err
+	}
+
+	signed, ok := src.(source.Signed)
+	if !ok {
+		return fmt.Errorf("%s cannot carry a signature: use a zip, tar.gz or registry package", src)
+	}
+	docs, err := signed.Signatures(ctx)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return fmt.Errorf("%s is not signed", src)
+	}
+
+	digest := src.(source.Verified).ManifestDigest()
+	var errs []error
+	for _, doc := range docs {
+		sig, err := signature.Parse(doc)
+		if err == nil {
+			err = sig.Verify(key, digest)
+		}
+		if err == nil {
+			fmt.Printf("Verified signature of %s by key %s\n", src, sig.KeyID)
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("no valid signature for %s: %v", src, errors.Join(errs...))
+}
+
+// provenance returns the source recorded in the loaded package's manifest.
+func (p *packageFlags) provenance() manifest.Source {
+	if p.manifest == nil {
+		return manifest.Source{}
+	}
+	return p.manifest.Source
+}
+
+// registryFlags selects how registries are authenticated.
+type registryFlags struct {
+	auth string
+	// azure is the identity exchanged for registry tokens in acr mode; it
+	// defaults to the one described by the environment.
+	azure *synapse.Credentials
+}
+
+func (r *registryFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&r.auth, "registry-auth", "", "registry auth mode: env, docker, acr or anonymous (default $REGISTRY_AUTH, else env credentials, then Docker config, then anonymous)")
+}
+
+// provider returns the credential provider of the selected auth mode.
+func (r *registryFlags) provider() (registry.CredentialProvider, error) {
+	mode := r.auth
+	if mode == "" {
+		mode = os.Getenv("REGISTRY_AUTH")
+	}
+
+	azure := r.azure
+	if azure == nil {
+		cloud, err := synapse.CloudByName(os.Getenv("AZURE_CLOUD"))
+		if err != nil {
+			return nil, err
+		}
+		creds := synapse.CredentialsFromEnv()
+		creds.Auth = os.Getenv("SYNAPSE_AUTH")
+		creds.Cloud = cloud
+		azure = &creds
+	}
+	return registry.ProviderFor(mode, *azure)
+}
+
+// sourceFlags records where a package built from a directory comes from.
+type sourceFlags struct {
+	src manifest.Source
+}
+
+func (s *sourceFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&s.src.Repository, "repo-url", "", "repository URL recorded in the manifest (default from CI variables or git)")
+	fs.StringVar(&s.src.Commit, "commit", "", "commit SHA recorded in the manifest (default from CI variables or git)")
+}
+
+// gitLabSource returns the source for the selected GitLab project.
+func (p *packageFlags) gitLabSource() (source.ArtifactSource, error) {
+	if p.gitlabRef == "" {
+		return nil, fmt.Errorf("-gitlab-ref is required with -gitlab-project")
+	}
+
+	privateToken := os.Getenv("GITLAB_PRIVATE_TOKEN")
+	if privateToken == "" {
+		return nil, fmt.Errorf("GITLAB_PRIVATE_TOKEN environment variable must be set")
+	}
+
+	client, err := gitlab.NewClient(gitlab.Options{
+		BaseURL:      firstSet(p.gitlabURL, os.Getenv("GITLAB_URL"), os.Getenv("CI_SERVER_URL")),
+		CABundle:     firstSet(p.gitlabCABundle, os.Getenv("GITLAB_CA_BUNDLE"), os.Getenv("CI_SERVER_TLS_CA_FILE")),
+		PrivateToken: privateToken,
+		ProjectID:    p.gitlabProject,
+		Ref:          p.gitlabRef,
+		Root:         p.gitlabRoot,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &source.GitLab{Client: client}, nil
+}
+
+// firstSet returns the first non-empty value.
+func firstSet(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// workspaceFlags selects a Synapse workspace and the identity used to reach
+// it. Values layer as config defaults < config environment < environment
+// variables < flags.
+type workspaceFlags struct {
+	configPath    string
+	env           string
+	name          string
+	subscription  string
+	resourceGroup string
+	cloud         synapse.Cloud
+	creds         synapse.Credentials
+
+	fs *flag.FlagSet
+}
+
+func (w *workspaceFlags) register(fs *flag.FlagSet) {
+	w.fs = fs
+	fs.StringVar(&w.configPath, "config", "", "configuration file declaring environments (default "+config.DefaultPath+" when present)")
+	fs.StringVar(&w.env, "env", "", "environment of the configuration file to use")
+	fs.StringVar(&w.name, "workspace", "", "name of the Synapse workspace (default $SYNAPSE_WORKSPACE)")
+	fs.StringVar(&w.subscription, "subscription", "", "Azure subscription ID of the workspace; with -resource-group, the workspace is looked up there (default $AZURE_SUBSCRIPTION_ID)")
+	fs.StringVar(&w.resourceGroup, "resource-group", "", "resource group of the workspace; with -subscription, the workspace is looked up there (default $AZURE_RESOURCE_GROUP)")
+	fs.StringVar(&w.cloud.Name, "cloud", "", "Azure cloud: public, china or usgovernment (default $AZURE_CLOUD)")
+	fs.StringVar(&w.creds.Auth, "auth", "", "auth mode: client-secret, default, managed-identity or azure-cli (default $SYNAPSE_AUTH)")
+	fs.StringVar(&w.creds.TenantID, "tenant-id", "", "Azure AD tenant ID (default $AZURE_TENANT_ID)")
+	fs.StringVar(&w.creds.ClientID, "client-id", "", "service principal or managed identity client ID (default $AZURE_CLIENT_ID)")
+}
+
+// resolve layers the configuration file, environment variables and the
+// flags set on the command line, and returns the resulting environment. It
+// must be called after the flags are parsed.
+func (w *workspaceFlags) resolve() (config.Environment, error) {
+	var env config.Environment
+	configPath := w.configPath
+	if configPath == "" {
+		if _, err := os.Stat(config.DefaultPath); err == nil {
+			configPath = config.DefaultPath
+		}
+	}
+	if configPath != "" {
+		f, err := config.Load(configPath)
+		if err != nil {
+			return env, err
+		}
+		env, err = f.Environment(w.env)
+		if err != nil {
+			return env, err
+		}
+	} else if w.env != "" {
+		return env, fmt.Errorf("-env %s needs a configuration file: %s not found", w.env, config.DefaultPath)
+	}
+
+	env = env.Merge(config.FromEnv(os.Getenv))
+
+	set := make(map[string]bool)
+	w.fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	env = env.Merge(config.Environment{
+		Workspace:     flagValue(set, "workspace", w.name),
+		Subscription:  flagValue(set, "subscription", w.subscription),
+		ResourceGroup: flagValue(set, "resource-group", w.resourceGroup),
+		Cloud:         flagValue(set, "cloud", w.cloud.Name),
+		Auth:          flagValue(set, "auth", w.creds.Auth),
+		TenantID:      flagValue(set, "tenant-id", w.creds.TenantID),
+		ClientID:      flagValue(set, "client-id", w.creds.ClientID),
+	})
+
+	cloud, err := synapse.CloudByName(env.Cloud)
+	if err != nil {
+		return env, err
+	}
+	if err := synapse.CheckAuth(env.Auth); err != nil {
+		return env, err
+	}
+
+	w.name = env.Workspace
+	w.subscription = env.Subscription
+	w.resourceGroup = env.ResourceGroup
+	w.cloud = cloud
+	w.creds = synapse.Credentials{
+		TenantID:     env.TenantID,
+		ClientID:     env.ClientID,
+		ClientSecret: os.Getenv("AZURE_CLIENT_SECRET"),
+		Auth:         env.Auth,
+		Cloud:        cloud,
+	}
+	return env, nil
+}
+
+// flagValue returns value when the named flag was set on the command line.
+func flagValue(set map[string]bool, name, value string) string {
+	if set[name] {
+		return value
+	}
+	return ""
+}
+
+// client creates a data-plane client for the selected workspace.
+func (w *workspaceFlags) client(ctx context.Context) (*synapse.Client, error) {
+	if w.name == "" {
+		return nil, fmt.Errorf("-workspace is required")
+	}
+
+	endpoint, err := w.endpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := w.creds.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain access token: %v", err)
+	}
+
+	client := synapse.NewClient(w.name, token)
+	client.Endpoint = endpoint
+	return client, nil
+}
+
+// endpoint returns the development endpoint of the selected workspace. With
+// a subscription and resource group, the workspace is looked up in Azure
+// Resource Manager, which confirms it belongs to them.
+func (w *workspaceFlags) endpoint(ctx context.Context) (string, error) {
+	return workspaceEndpoint(ctx, w.creds, w.name, w.subscription, w.resourceGroup)
+}
+
+// workspaceEndpoint returns the development endpoint of the named workspace,
+// looked up in Azure Resource Manager when subscription and resourceGroup
+// are both set.
+func workspaceEndpoint(ctx context.Context, creds synapse.Credentials, name, subscription, resourceGroup string) (string, error) {
+	if subscription == "" || resourceGroup == "" {
+		return creds.Cloud.Endpoint(name), nil
+	}
+	return creds.WorkspaceEndpoint(ctx, subscription, resourceGroup, name)
+}
+
+// targetFlags selects where a deploy run writes artifacts.
+type targetFlags struct {
+	kind string
+	out  string
+}
+
+func (t *targetFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&t.kind, "target", "rest", "deploy target: rest, sdk or local")
+	fs.StringVar(&t.out, "out", "", "directory or .zip file written by the local target")
+}
+
+// open creates the selected target. Workspace targets use ws.
+func (t *targetFlags) open(ctx context.Context, ws *workspaceFlags) (target.ArtifactTarget, error) {
+	switch t.kind {
+	case "rest":
+		client, err := ws.client(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &target.REST{Client: client}, nil
+	case "sdk":
+		if ws.name == "" {
+			return nil, fmt.Errorf("-workspace is required")
+		}
+		endpoint, err := ws.endpoint(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cred, err := ws.creds.TokenCredential()
+		if err != nil {
+			return nil, err
+		}
+		return target.NewSDK(endpoint, ws.cloud, cred, nil), nil
+	case "local":
+		if t.out == "" {
+			return nil, fmt.Errorf("-out is required with -target local")
+		}
+		return target.OpenLocal(t.out), nil
+	default:
+		return nil, fmt.Errorf("unknown target %q", t.kind)
+	}
+}
+
+// stringList is a flag that can be repeated to collect several values.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// pruneFlags selects whether and what a deploy deletes.
+type pruneFlags struct {
+	enabled bool
+	exclude stringList
+}
+
+func (p *pruneFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&p.enabled, "prune", false, "delete target artifacts that are missing from the package")
+	fs.Var(&p.exclude, "prune-exclude", "never prune artifacts matching this pattern (type/name or name glob); repeatable")
+}
+
+// filter returns the prune filter for the exclusion patterns.
+func (p *pruneFlags) filter() (prune.Filter, error) {
+	f := prune.Filter{Exclude: p.exclude}
+	return f, f.Validate()
+}
+
+// parameterFlags selects the parameter files applied to a package and how
+// their placeholders are resolved.
+type parameterFlags struct {
+	files       stringList
+	secretsFile string
+}
+
+func (p *parameterFlags) register(fs *flag.FlagSet) {
+	fs.Var(&p.files, "parameters", "YAML parameter file setting values by type/name:path; repeatable, applied in order")
+	fs.StringVar(&p.secretsFile, "secrets-file", "", "YAML file serving ${keyvault:vault/secret} placeholders instead of Key Vault")
+}
+
+// apply applies the parameter files to artifacts, resolving ${env:...},
+// ${file:...} and ${keyvault:...} placeholders in their values. Key Vault
+// is reached with creds.
+func (p *parameterFlags) apply(ctx context.Context, artifacts []artifact.Artifact, creds synapse.Credentials) error {
+	if len(p.files) == 0 {
+		return nil
+	}
+
+	var keyVault secret.Resolver = &secret.KeyVault{Credentials: creds}
+	if p.secretsFile != "" {
+		static, err := secret.LoadStatic(p.secretsFile)
+		if err != nil {
+			return err
+		}
+		keyVault = static
+	}
+	expander := &secret.Expander{
+		Resolvers: map[string]secret.Resolver{
+			"env":      secret.Env,
+			"file":     secret.File,
+			"keyvault": keyVault,
+		},
+		Redactor: secret.DefaultRedactor(),
+	}
+	expand := func(ctx context.Context, s string) (string, error) {
+		value, err := expander.Expand(ctx, s)
+		if value != s {
+			redactOutput()
+		}
+		return value, err
+	}
+
+	for _, file := range p.files {
+		params, err := transform.LoadParameters(file)
+		if err != nil {
+			return err
+		}
+		if err := params.Expand(ctx, expand); err != nil {
+			return fmt.Errorf("failed to resolve %s: %v", file, err)
+		}
+		if err := params.Apply(artifacts); err != nil {
+			return fmt.Errorf("failed to apply %s: %v", file, err)
+		}
+	}
+	return nil
+}
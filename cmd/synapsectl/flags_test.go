@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/archive"
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/config"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+	"github.com/utsavudhungana/artifactsrepo/packager"
+	"github.com/utsavudhungana/artifactsrepo/synapse"
+)
+
+const testConfig = `version: 1
+defaults:
+  cloud: public
+  auth: client-secret
+  tenantId: tenant-default
+  parameters: [parameters/common.yaml]
+environments:
+  prod:
+    workspace: ws-prod
+    subscription: sub-prod
+    resourceGroup: rg-prod
+    clientId: client-prod
+    parameters: [parameters/prod.yaml]
+`
+
+// envVars are the variables workspaceFlags reads, cleared for every test.
+var envVars = []string{
+	"SYNAPSE_WORKSPACE", "AZURE_SUBSCRIPTION_ID", "AZURE_RESOURCE_GROUP", "AZURE_CLOUD",
+	"SYNAPSE_AUTH", "AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET",
+}
+
+func TestWorkspaceFlagsResolve(t *testing.T) {
+	tests := []struct {
+		name string
+		// config is written to the working directory when set
+		config  string
+		env     map[string]string
+		args    []string
+		want    config.Environment
+		wantErr string
+	}{
+		{
+			name: "nothing set",
+			want: config.Environment{},
+		},
+		{
+			name:   "config defaults",
+			config: testConfig,
+			want: config.Environment{
+				Cloud: "public", Auth: "client-secret", TenantID: "tenant-default",
+				Parameters: []string{"parameters/common.yaml"},
+			},
+		},
+		{
+			name:   "config environment over defaults",
+			config: testConfig,
+			args:   []string{"-env", "prod"},
+			want: config.Environment{
+				Workspace: "ws-prod", Subscription: "sub-prod", ResourceGroup: "rg-prod",
+				Cloud: "public", Auth: "client-secret", TenantID: "tenant-default", ClientID: "client-prod",
+				Parameters: []string{"parameters/common.yaml", "parameters/prod.yaml"},
+			},
+		},
+		{
+			name:   "environment variables over config",
+			config: testConfig,
+			env:    map[string]string{"SYNAPSE_WORKSPACE": "ws-env", "AZURE_TENANT_ID": "tenant-env", "AZURE_CLOUD": "china"},
+			args:   []string{"-env", "prod"},
+			want: config.Environment{
+				Workspace: "ws-env", Subscription: "sub-prod", ResourceGroup: "rg-prod",
+				Cloud: "china", Auth: "client-secret", TenantID: "tenant-env", ClientID: "client-prod",
+				Parameters: []string{"parameters/common.yaml", "parameters/prod.yaml"},
+			},
+		},
+		{
+			name:   "flags over everything",
+			config: testConfig,
+			env:    map[string]string{"SYNAPSE_WORKSPACE": "ws-env", "SYNAPSE_AUTH": "default"},
+			args: []string{"-env", "prod", "-workspace", "ws-flag", "-auth", "azure-cli", "-subscription", "sub-flag",
+				"-resource-group", "rg-flag", "-cloud", "usgovernment", "-tenant-id", "tenant-flag", "-client-id", "client-flag"},
+			want: config.Environment{
+				Workspace: "ws-flag", Subscription: "sub-flag", ResourceGroup: "rg-flag",
+				Cloud: "usgovernment", Auth: "azure-cli", TenantID: "tenant-flag", ClientID: "client-flag",
+				Parameters: []string{"parameters/common.yaml", "parameters/prod.yaml"},
+			},
+		},
+		{
+			name:   "flags set to empty values do not clear",
+			config: testConfig,
+			args:   []string{"-env", "prod", "-workspace", ""},
+			want: config.Environment{
+				Workspace: "ws-prod", Subscription: "sub-prod", ResourceGroup: "rg-prod",
+				Cloud: "public", Auth: "client-secret", TenantID: "tenant-default", ClientID: "client-prod",
+				Parameters: []string{"parameters/common.yaml", "parameters/prod.yaml"},
+			},
+		},
+		{
+			name:    "explicit config path instead of the default one",
+			config:  testConfig,
+			args:    []string{"-config", filepath.Join("conf", "other.yaml"), "-env", "prod"},
+			wantErr: "failed to read config file",
+		},
+		{
+			name:    "env without a config file",
+			args:    []string{"-env", "prod"},
+			wantErr: "-env prod needs a configuration file: synapsectl.yaml not found",
+		},
+		{
+			name:    "unknown environment",
+			config:  testConfig,
+			args:    []string{"-env", "test"},
+			wantErr: `unknown environment "test", have: prod`,
+		},
+		{
+			name:    "unknown cloud",
+			env:     map[string]string{"AZURE_CLOUD": "mars"},
+			wantErr: `unknown cloud "mars"`,
+		},
+		{
+			name:    "unknown auth",
+			args:    []string{"-auth", "password"},
+			wantErr: `unknown auth mode "password"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			for _, key := range envVars {
+				t.Setenv(key, "")
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			if tt.config != "" {
+				if err := os.WriteFile(config.DefaultPath, []byte(tt.config), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var w workspaceFlags
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			w.register(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := w.resolve()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolve error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolve =\n%+v\nwant\n%+v", got, tt.want)
+			}
+			if w.name != tt.want.Workspace || w.creds.TenantID != tt.want.TenantID || w.creds.Auth != tt.want.Auth {
+				t.Errorf("flags not updated: workspace %q, tenant %q, auth %q", w.name, w.creds.TenantID, w.creds.Auth)
+			}
+		})
+	}
+}
+
+func TestWorkspaceFlagsConfigRelativeParameters(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(t.TempDir())
+	for _, key := range envVars {
+		t.Setenv(key, "")
+	}
+	configPath := filepath.Join(dir, "deploy", "synapsectl.yaml")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte(testConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AZURE_CLIENT_SECRET", "secret")
+
+	var w workspaceFlags
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	w.register(fs)
+	if err := fs.Parse([]string{"-config", configPath, "-env", "prod", "-cloud", "china"}); err != nil {
+		t.Fatal(err)
+	}
+	env, err := w.resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		filepath.Join(dir, "deploy", "parameters", "common.yaml"),
+		filepath.Join(dir, "deploy", "parameters", "prod.yaml"),
+	}
+	if !reflect.DeepEqual(env.Parameters, want) {
+		t.Errorf("parameters = %v, want them relative to the config file: %v", env.Parameters, want)
+	}
+	if w.cloud.Name != synapse.ChinaCloud.Name || w.creds.Cloud.Name != synapse.ChinaCloud.Name {
+		t.Errorf("cloud = %q, credentials cloud = %q, want %q", w.cloud.Name, w.creds.Cloud.Name, synapse.ChinaCloud.Name)
+	}
+	if w.creds.ClientSecret != "secret" || w.creds.ClientID != "client-prod" {
+		t.Errorf("credentials = %+v", w.creds)
+	}
+}
+
+func TestPackageFlagsManifest(t *testing.T) {
+	dir := t.TempDir()
+	nb := []byte(`{"name":"nb1"}`)
+	a, err := artifact.New("notebook/nb1.json", nb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	built, err := packager.Build([]artifact.Artifact{a}, manifest.Source{Commit: "abc123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bare, err := archive.Zip(map[string][]byte{"notebook/nb1.json": nb})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, data []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	write("checkout/notebook/nb1.json", nb)
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantErr      string
+		wantManifest bool
+	}{
+		{name: "built package", args: []string{"-package", write("built.zip", built)}, wantManifest: true},
+		{name: "zip without manifest", args: []string{"-package", write("bare.zip", bare)}, wantErr: "-allow-unverified"},
+		{name: "zip without manifest allowed", args: []string{"-package", filepath.Join(dir, "bare.zip"), "-allow-unverified"}},
+		{name: "directory", args: []string{"-package", filepath.Join(dir, "checkout")}},
+		{name: "directory with a verify key", args: []string{"-package", filepath.Join(dir, "checkout"), "-verify-key", "key.pem"}, wantErr: "has no manifest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p packageFlags
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			p.register(fs)
+			p.registerVerify(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			artifacts, err := p.artifacts(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("artifacts error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(artifacts) != 1 || artifacts[0].Key() != "notebook/nb1" {
+				t.Errorf("artifacts = %v, want notebook/nb1", artifacts)
+			}
+			if (p.manifest != nil) != tt.wantManifest {
+				t.Errorf("manifest = %v, want one: %v", p.manifest, tt.wantManifest)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/graph"
+)
+
+func runGraph(args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	var pkg packageFlags
+	pkg.register(fs)
+	format := fs.String("format", "mermaid", "output format: mermaid, markdown, dot or json")
+	failOnMissing := fs.Bool("fail-on-missing", false, "exit with an error when an artifact references one missing from the package")
+	fs.Parse(args)
+
+	artifacts, err := pkg.artifacts(context.Background())
+	if err != nil {
+		return err
+	}
+
+	g, err := graph.Build(artifacts)
+	if err != nil {
+		return err
+	}
+	d := g.Diagram()
+
+	if err := writeDiagram(os.Stdout, d, *format); err != nil {
+		return err
+	}
+
+	missing := d.Missing()
+	for _, n := range missing {
+		fmt.Fprintf(os.Stderr, "Missing artifact: %s\n", n.ID)
+	}
+	if *failOnMissing && len(missing) > 0 {
+		ids := make([]string, len(missing))
+		for i, n := range missing {
+			ids[i] = n.ID
+		}
+		return fmt.Errorf("referenced artifacts missing from the package: %s", strings.Join(ids, ", "))
+	}
+	return nil
+}
+
+// writeDiagram renders d in format to w.
+func writeDiagram(w io.Writer, d graph.Diagram, format string) error {
+	switch format {
+	case "mermaid":
+		return d.WriteMermaid(w)
+	case "markdown":
+		// Azure DevOps wiki layout, as used by synapse-diagram.md
+		if _, err := fmt.Fprintln(w, "Synapse artifacts\n::: mermaid"); err != nil {
+			return err
+		}
+		if err := d.WriteMermaid(w); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w, ":::")
+		return err
+	case "dot":
+		return d.WriteDOT(w)
+	case "json":
+		return d.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
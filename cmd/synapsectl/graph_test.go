@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/graph"
+	"github.com/utsavudhungana/artifactsrepo/source"
+)
+
+// TestDiagramUpToDate fails when synapse-diagram.md no longer matches the
+// artifacts at the repository root, such as after one was added.
+func TestDiagramUpToDate(t *testing.T) {
+	root := filepath.Join("..", "..")
+	src := &source.Directory{Root: root}
+	artifacts, err := src.Artifacts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := graph.Build(artifacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	if err := writeDiagram(&got, g.Diagram(), "markdown"); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile(filepath.Join(root, "synapse-diagram.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("synapse-diagram.md is out of date; regenerate it from the repository root with\n"+
+			"    go run ./cmd/synapsectl graph -package . -format markdown > synapse-diagram.md\n"+
+			"got:\n%s", got.String())
+	}
+}
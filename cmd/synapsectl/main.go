@@ -0,0 +1,122 @@
+// Command synapsectl packages Synapse workspace artifacts, moves them through
+// OCI registries and deploys them to Synapse workspaces.
+//
+// Usage:
+//
+//	synapsectl [global flags] <command> [flags]
+//
+// Run "synapsectl <command> -h" for the flags of a command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/utsavudhungana/artifactsrepo/httpclient"
+	"github.com/utsavudhungana/artifactsrepo/secret"
+)
+
+// command is a synapsectl subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"package", "zip artifact definitions from a directory, or sign a package (package sign)", runPackage},
+	{"push", "push an artifact package to an OCI registry", runPush},
+	{"pull", "pull an artifact package from an OCI registry", runPull},
+	{"diff", "list the artifacts that differ between two layered packages", runDiff},
+	{"promote", "copy a package and its signatures to another repository and tag it for an environment", runPromote},
+	{"deploy", "publish an artifact package to a Synapse workspace", runDeploy},
+	{"plan", "show what a deploy would change without writing anything", runPlan},
+	{"export", "download artifact definitions from a Synapse workspace", runExport},
+	{"migrate", "copy the artifacts of one workspace to another", runMigrate},
+	{"graph", "render the artifact dependency graph", runGraph},
+	{"token", "print a Synapse access token", runToken},
+	{"parameters", "write a parameter file from a template parameter definition", runParameters},
+	{"transform", "apply parameter files to an artifact package", runTransform},
+}
+
+func main() {
+	opts := httpclient.DefaultOptions()
+	flag.DurationVar(&opts.Timeout, "http-timeout", opts.Timeout, "time limit for a single HTTP call, retries included")
+	flag.DurationVar(&opts.ResponseHeaderTimeout, "http-response-timeout", opts.ResponseHeaderTimeout, "time limit for response headers of one attempt")
+	flag.IntVar(&opts.MaxRetries, "http-retries", opts.MaxRetries, "retries of throttled or failed HTTP calls")
+	flag.DurationVar(&opts.MaxBackoff, "http-max-backoff", opts.MaxBackoff, "longest backoff between HTTP retries")
+	flag.Usage = usage
+	flag.Parse()
+	httpclient.SetDefault(httpclient.New(opts))
+
+	args := flag.Args()
+	if len(args) < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := args[0]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			err := cmd.run(args[1:])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "synapsectl %s: %v\n", name, err)
+			}
+			flushOutput()
+			if err != nil {
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	if name != "help" {
+		fmt.Fprintf(os.Stderr, "synapsectl: unknown command %q\n", name)
+	}
+	usage()
+	os.Exit(2)
+}
+
+// flushOutput restores stdout and stderr after redactOutput and passes on
+// what is still buffered.
+var flushOutput = func() {}
+
+var redactOnce sync.Once
+
+// redactOutput routes stdout and stderr through the secret redactor, so no
+// resolved secret reaches the log. It is installed when the first secret is
+// resolved; output before that, such as flag usage, is written directly.
+func redactOutput() {
+	redactOnce.Do(func() {
+		redactor := secret.DefaultRedactor()
+		restoreStdout, err := redactor.Capture(&os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "synapsectl: failed to redact output: %v\n", err)
+			os.Exit(1)
+		}
+		restoreStderr, err := redactor.Capture(&os.Stderr)
+		if err != nil {
+			restoreStdout()
+			fmt.Fprintf(os.Stderr, "synapsectl: failed to redact output: %v\n", err)
+			os.Exit(1)
+		}
+		flushOutput = func() {
+			restoreStderr()
+			restoreStdout()
+		}
+	})
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: synapsectl [global flags] <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Global flags:")
+	flag.PrintDefaults()
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/config"
+	"github.com/utsavudhungana/artifactsrepo/deploy"
+	"github.com/utsavudhungana/artifactsrepo/export"
+	"github.com/utsavudhungana/artifactsrepo/graph"
+	"github.com/utsavudhungana/artifactsrepo/migrate"
+	"github.com/utsavudhungana/artifactsrepo/plan"
+	"github.com/utsavudhungana/artifactsrepo/secret"
+	"github.com/utsavudhungana/artifactsrepo/synapse"
+	"github.com/utsavudhungana/artifactsrepo/target"
+)
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	var ws workspaceFlags
+	var prn pruneFlags
+	var params parameterFlags
+	var opts deploy.Options
+	ws.register(fs)
+	prn.register(fs)
+	params.register(fs)
+	source := fs.String("source", "", "name of the workspace to migrate from")
+	sourceEnv := fs.String("source-env", "", "environment of the configuration file whose workspace to migrate from")
+	yes := fs.Bool("yes", false, "apply the plan without asking for confirmation")
+	fs.IntVar(&opts.Parallelism, "parallelism", 4, "most artifacts published at once within a dependency level")
+	fs.BoolVar(&opts.ContinueOnError, "continue-on-error", false, "keep publishing after a failure, skipping artifacts that depend on it")
+	fs.Parse(args)
+
+	env, err := ws.resolve()
+	if err != nil {
+		return err
+	}
+	prn.exclude = append(stringList(env.Exclude), prn.exclude...)
+	params.files = append(stringList(env.Parameters), params.files...)
+
+	filter, err := prn.filter()
+	if err != nil {
+		return err
+	}
+
+	srcEnv, err := sourceWorkspace(*source, *sourceEnv, ws.configPath)
+	if err != nil {
+		return err
+	}
+	sourceName := srcEnv.Workspace
+
+	ctx := context.Background()
+	dest, err := ws.client(ctx)
+	if err != nil {
+		return err
+	}
+	if sourceName == ws.name {
+		return fmt.Errorf("source and target are both workspace %s", ws.name)
+	}
+
+	// Both workspaces are reached with the same identity
+	srcEndpoint, err := workspaceEndpoint(ctx, ws.creds, sourceName, srcEnv.Subscription, srcEnv.ResourceGroup)
+	if err != nil {
+		return err
+	}
+	src := synapse.NewClient(sourceName, dest.Token)
+	src.Endpoint = srcEndpoint
+
+	fmt.Printf("Exporting artifacts from workspace %s\n", sourceName)
+	artifacts, err := export.Workspace(ctx, &target.REST{Client: src}, nil)
+	if err != nil {
+		return err
+	}
+	artifacts = migrate.Retarget(artifacts, ws.name)
+
+	if err := params.apply(ctx, artifacts, ws.creds); err != nil {
+		return err
+	}
+
+	g, err := graph.Build(artifacts)
+	if err != nil {
+		return err
+	}
+	ordered, err := g.Sort()
+	if err != nil {
+		return err
+	}
+
+	rest := &target.REST{Client: dest}
+	p, err := plan.Build(ctx, ordered, rest, secret.DefaultRedactor())
+	if err != nil {
+		return err
+	}
+	if prn.enabled {
+		if err := p.Prune(ctx, ordered, rest, filter); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Migrating %s to %s:\n", sourceName, ws.name)
+	p.Write(os.Stdout)
+	if p.Count(plan.Create)+p.Count(plan.Update)+p.Count(plan.Delete) == 0 {
+		return nil
+	}
+
+	if !*yes && !confirm("Apply these changes?") {
+		return fmt.Errorf("migration cancelled")
+	}
+
+	// Unchanged artifacts already exist in the target, so only the rest is
+	// published; references to unchanged ones are satisfied.
+	changed := make(map[string]bool)
+	for _, c := range p.Changes {
+		if c.Action == plan.Create || c.Action == plan.Update {
+			changed[c.Key()] = true
+		}
+	}
+	var publish []artifact.Artifact
+	for _, a := range artifacts {
+		if changed[a.Key()] {
+			publish = append(publish, a)
+		}
+	}
+
+	g, err = graph.Build(publish)
+	if err != nil {
+		return err
+	}
+	if err := deploy.Publish(ctx, rest, g, opts); err != nil {
+		return err
+	}
+
+	if prn.enabled {
+		if err := pruneTarget(ctx, rest, artifacts, filter); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("Artifacts migrated successfully.")
+	return nil
+}
+
+// sourceWorkspace returns the -source workspace, or the workspace of the
+// -source-env environment with its subscription and resource group.
+func sourceWorkspace(name, envName, configPath string) (config.Environment, error) {
+	switch {
+	case name != "" && envName != "":
+		return config.Environment{}, fmt.Errorf("-source and -source-env are mutually exclusive")
+	case name != "":
+		return config.Environment{Workspace: name}, nil
+	case envName == "":
+		return config.Environment{}, fmt.Errorf("-source or -source-env is required")
+	}
+
+	if configPath == "" {
+		configPath = config.DefaultPath
+	}
+	f, err := config.Load(configPath)
+	if err != nil {
+		return config.Environment{}, err
+	}
+	env, err := f.Environment(envName)
+	if err != nil {
+		return config.Environment{}, err
+	}
+	if env.Workspace == "" {
+		return config.Environment{}, fmt.Errorf("environment %s has no workspace", envName)
+	}
+	return env, nil
+}
+
+// confirm asks a yes/no question on stdin; anything but y or yes is no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/utsavudhungana/artifactsrepo/packager"
+)
+
+func runPackage(args []string) error {
+	if len(args) > 0 && args[0] == "sign" {
+		return runPackageSign(args[1:])
+	}
+
+	fs := flag.NewFlagSet("package", flag.ExitOnError)
+	dir := fs.String("dir", ".", "directory holding the artifact definitions")
+	out := fs.String("out", "artifacts.zip", "path of the zip package to write")
+	var src sourceFlags
+	src.register(fs)
+	fs.Parse(args)
+
+	data, err := packager.Directory(*dir, src.src)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write package: %v", err)
+	}
+
+	fmt.Printf("Wrote package %s\n", *out)
+	return nil
+}
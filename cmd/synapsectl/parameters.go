@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/utsavudhungana/artifactsrepo/transform"
+)
+
+func runParameters(args []string) error {
+	fs := flag.NewFlagSet("parameters", flag.ExitOnError)
+	var pkg packageFlags
+	pkg.register(fs)
+	definition := fs.String("definition", "template-parameters-definition.json", "template parameter definition selecting the properties to parameterize")
+	armParameters := fs.String("arm-parameters", "", "ARM template parameter file of an environment whose values fill the skeleton")
+	out := fs.String("out", "", "path of the parameter file to write; prints it when empty")
+	fs.Parse(args)
+
+	def, err := transform.LoadDefinition(*definition)
+	if err != nil {
+		return err
+	}
+
+	artifacts, err := pkg.artifacts(context.Background())
+	if err != nil {
+		return err
+	}
+
+	params, err := def.Parameters(artifacts)
+	if err != nil {
+		return err
+	}
+
+	var values map[string]any
+	if *armParameters != "" {
+		values, err = transform.LoadARMParameters(*armParameters)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := transform.Skeleton(params, values)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write parameter file: %v", err)
+	}
+
+	fmt.Printf("Wrote %d parameters to %s\n", len(params), *out)
+	return nil
+}
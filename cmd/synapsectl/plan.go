@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/utsavudhungana/artifactsrepo/graph"
+	"github.com/utsavudhungana/artifactsrepo/plan"
+	"github.com/utsavudhungana/artifactsrepo/secret"
+	"github.com/utsavudhungana/artifactsrepo/target"
+)
+
+func runPlan(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	var pkg packageFlags
+	var ws workspaceFlags
+	var tgt targetFlags
+	var prn pruneFlags
+	var params parameterFlags
+	pkg.register(fs)
+	ws.register(fs)
+	tgt.register(fs)
+	prn.register(fs)
+	params.register(fs)
+	fs.Parse(args)
+
+	env, err := ws.resolve()
+	if err != nil {
+		return err
+	}
+	pkg.registry.azure = &ws.creds
+	prn.exclude = append(stringList(env.Exclude), prn.exclude...)
+	params.files = append(stringList(env.Parameters), params.files...)
+
+	filter, err := prn.filter()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	artifacts, err := pkg.artifacts(ctx)
+	if err != nil {
+		return err
+	}
+	if err := params.apply(ctx, artifacts, ws.creds); err != nil {
+		return err
+	}
+
+	g, err := graph.Build(artifacts)
+	if err != nil {
+		return err
+	}
+	artifacts, err = g.Sort()
+	if err != nil {
+		return err
+	}
+
+	dest, err := tgt.open(ctx, &ws)
+	if err != nil {
+		return err
+	}
+
+	// A plan never writes: targets that cannot be read back, such as a zip
+	// that is written on Close, are rejected without being closed
+	reader, ok := dest.(target.Reader)
+	if !ok {
+		return fmt.Errorf("target %s cannot be read back for a plan", dest)
+	}
+	defer dest.Close()
+
+	p, err := plan.Build(ctx, artifacts, reader, secret.DefaultRedactor())
+	if err != nil {
+		return err
+	}
+	if prn.enabled {
+		if err := p.Prune(ctx, artifacts, reader, filter); err != nil {
+			return err
+		}
+	}
+
+	p.Write(os.Stdout)
+	return nil
+}
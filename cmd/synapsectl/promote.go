@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/utsavudhungana/artifactsrepo/registry"
+)
+
+func runPromote(args []string) error {
+	fs := flag.NewFlagSet("promote", flag.ExitOnError)
+	from := fs.String("from", "", "package to promote: registry/repository:tag or @digest, or oci:path[:tag|@digest]")
+	to := fs.String("to", "", "repository to promote to, registry/repository[:tag] or oci:path[:tag]; the tag defaults to the -from tag")
+	env := fs.String("env", "", "environment the package is promoted to, pushed as a tag and recorded")
+	by := fs.String("by", "", "who promotes the package (default the CI user, then $USER)")
+	var reg registryFlags
+	reg.register(fs)
+	fs.Parse(args)
+
+	if *from == "" || *to == "" || *env == "" {
+		return fmt.Errorf("-from, -to and -env are required")
+	}
+
+	cred, err := reg.provider()
+	if err != nil {
+		return err
+	}
+	src, srcRef, err := registry.Open(*from, cred)
+	if err != nil {
+		return err
+	}
+	dst, tag, err := registry.Open(*to, cred)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(tag, ":") {
+		return fmt.Errorf("cannot promote to digest reference %s", *to)
+	}
+	if tag == "" && !strings.Contains(srcRef, ":") {
+		tag = srcRef
+	}
+
+	p := registry.Promotion{
+		Environment: *env,
+		By:          *by,
+		At:          time.Now(),
+		From:        *from,
+	}
+	if p.By == "" {
+		p.By = promoter()
+	}
+	if tag != "" {
+		p.Tags = []string{tag}
+	}
+
+	desc, err := registry.Promote(context.Background(), src, srcRef, dst, p)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Promoted %s to %s as %s with digest %s\n", *from, *to, strings.Join(append(p.Tags, p.Environment), ", "), desc.Digest)
+	return nil
+}
+
+// promoter names the user running the promotion: the user who triggered the
+// GitLab, Azure Pipelines or GitHub Actions run, or the local user.
+func promoter() string {
+	for _, name := range []string{"GITLAB_USER_LOGIN", "BUILD_REQUESTEDFOR", "GITHUB_ACTOR", "USER", "USERNAME"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return "unknown"
+}
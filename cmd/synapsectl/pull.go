@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/archive"
+	"github.com/utsavudhungana/artifactsrepo/registry"
+)
+
+func runPull(args []string) error {
+	fs := flag.NewFlagSet("pull", flag.ExitOnError)
+	ref := fs.String("ref", "", "package reference: registry/repository:tag, registry/repository@digest or oci:path[:tag|@digest]")
+	out := fs.String("out", "artifacts.zip", "path of the zip package to write")
+	extract := fs.String("extract", "", "extract the package into this directory instead of writing -out")
+	types := fs.String("type", "", "comma-separated artifact types to pull, for example notebook,sqlscript (default all); the package manifest is left out")
+	var reg registryFlags
+	reg.register(fs)
+	fs.Parse(args)
+
+	if *ref == "" {
+		return fmt.Errorf("-ref is required")
+	}
+
+	cred, err := reg.provider()
+	if err != nil {
+		return err
+	}
+
+	var files map[string][]byte
+	var data []byte
+	if *types != "" {
+		files, err = registry.PullTypes(context.Background(), *ref, strings.Split(*types, ","), cred)
+		if err == nil && *extract == "" {
+			data, err = archive.Zip(files)
+		}
+	} else {
+		data, err = registry.Pull(context.Background(), *ref, cred)
+		if err == nil && *extract != "" {
+			files, err = archive.Unzip(data)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if *extract != "" {
+		if err := archive.Extract(files, *extract); err != nil {
+			return err
+		}
+		fmt.Printf("Extracted %d files to %s\n", len(files), *extract)
+		return nil
+	}
+
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write package: %v", err)
+	}
+	fmt.Printf("Wrote package %s\n", *out)
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/utsavudhungana/artifactsrepo/archive"
+	"github.com/utsavudhungana/artifactsrepo/packager"
+	"github.com/utsavudhungana/artifactsrepo/registry"
+)
+
+func runPush(args []string) error {
+	fs := flag.NewFlagSet("push", flag.ExitOnError)
+	ref := fs.String("ref", "", "registry repository to push to (registry/repository[:tag], default tag latest), or oci:path[:tag] for an OCI layout")
+	dir := fs.String("dir", ".", "directory holding the artifact definitions")
+	pkg := fs.String("package", "", "push an existing zip package instead of packaging -dir")
+	layout := fs.String("layout", registry.LayoutZip, "package layout: zip (one layer) or layers (one layer per artifact, shared by digest across pushes)")
+	var src sourceFlags
+	src.register(fs)
+	var reg registryFlags
+	reg.register(fs)
+	fs.Parse(args)
+
+	if *ref == "" {
+		return fmt.Errorf("-ref is required")
+	}
+
+	var data []byte
+	var err error
+	if *pkg != "" {
+		data, err = archive.ReadFile(*pkg)
+	} else {
+		data, err = packager.Directory(*dir, src.src)
+	}
+	if err != nil {
+		return err
+	}
+
+	cred, err := reg.provider()
+	if err != nil {
+		return err
+	}
+	desc, err := registry.Push(context.Background(), *ref, data, *layout, cred)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pushed package %s with digest %s\n", *ref, desc.Digest)
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/utsavudhungana/artifactsrepo/registry"
+	"github.com/utsavudhungana/artifactsrepo/signature"
+	"github.com/utsavudhungana/artifactsrepo/source"
+)
+
+// runPackageSign implements "package sign": it signs the manifest digest of
+// a zip or tar.gz package into a detached .sig file, or of a registry
+// package into a referrer of its manifest.
+func runPackageSign(args []string) error {
+	fs := flag.NewFlagSet("package sign", flag.ExitOnError)
+	keyPath := fs.String("key", "", "PEM private key, ed25519 or ECDSA")
+	pkgPath := fs.String("package", "", "zip or tar.gz package to sign; the signature is written next to it with a .sig extension")
+	ref := fs.String("ref", "", "registry package to sign (registry/repository:tag or @digest, or oci:path:tag); the signature is attached as a referrer")
+	var reg registryFlags
+	reg.register(fs)
+	fs.Parse(args)
+
+	if *keyPath == "" {
+		return fmt.Errorf("-key is required")
+	}
+	if (*pkgPath == "") == (*ref == "") {
+		return fmt.Errorf("one of -package or -ref is required")
+	}
+	key, err := signature.LoadPrivateKey(*keyPath)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var src source.ArtifactSource
+	var oci *source.OCI
+	if *ref != "" {
+		cred, err := reg.provider()
+		if err != nil {
+			return err
+		}
+		oci = &source.OCI{Reference: *ref, Credentials: cred}
+		src = oci
+	} else {
+		src, err = source.Open(*pkgPath)
+		if err != nil {
+			return err
+		}
+		if _, ok := src.(source.Signed); !ok {
+			return fmt.Errorf("%s cannot carry a signature: sign a zip or tar.gz package", src)
+		}
+	}
+
+	if _, err := src.Artifacts(ctx); err != nil {
+		return fmt.Errorf("failed to load artifacts from %s: %v", src, err)
+	}
+	digest := src.(source.Verified).ManifestDigest()
+	if digest == "" {
+		return fmt.Errorf("%s has no manifest to sign; build it with synapsectl package", src)
+	}
+
+	sig, err := signature.Sign(key, digest)
+	if err != nil {
+		return err
+	}
+	data, err := sig.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if oci != nil {
+		target, _, err := registry.Open(*ref, oci.Credentials)
+		if err != nil {
+			return err
+		}
+		desc, err := registry.AttachSignature(ctx, target, oci.Descriptor(), data)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Signed package %s (manifest %s) with key %s as %s\n", *ref, digest, sig.KeyID, desc.Digest)
+		return nil
+	}
+
+	sigPath := *pkgPath + signature.Extension
+	if err := os.WriteFile(sigPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write signature: %v", err)
+	}
+	fmt.Printf("Signed package %s (manifest %s) with key %s to %s\n", *pkgPath, digest, sig.KeyID, sigPath)
+	return nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+)
+
+func runToken(args []string) error {
+	fs := flag.NewFlagSet("token", flag.ExitOnError)
+	var ws workspaceFlags
+	ws.register(fs)
+	fs.Parse(args)
+
+	if _, err := ws.resolve(); err != nil {
+		return err
+	}
+
+	token, err := ws.creds.Token(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(token)
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/utsavudhungana/artifactsrepo/packager"
+)
+
+func runTransform(args []string) error {
+	fs := flag.NewFlagSet("transform", flag.ExitOnError)
+	var ws workspaceFlags
+	var pkg packageFlags
+	var params parameterFlags
+	ws.register(fs)
+	pkg.register(fs)
+	params.register(fs)
+	out := fs.String("out", "", "path of the zip package to write; prints the artifacts when empty")
+	fs.Parse(args)
+
+	env, err := ws.resolve()
+	if err != nil {
+		return err
+	}
+	pkg.registry.azure = &ws.creds
+	params.files = append(stringList(env.Parameters), params.files...)
+	if len(params.files) == 0 {
+		return fmt.Errorf("-parameters is required")
+	}
+
+	ctx := context.Background()
+	artifacts, err := pkg.artifacts(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := params.apply(ctx, artifacts, ws.creds); err != nil {
+		return err
+	}
+
+	if *out == "" {
+		for _, a := range artifacts {
+			fmt.Printf("Modified content of %s:\n%s\n", a.Path(), a.Content)
+		}
+		return nil
+	}
+
+	data, err := packager.Build(artifacts, pkg.provenance())
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write package: %v", err)
+	}
+
+	fmt.Printf("Wrote package %s\n", *out)
+	return nil
+}
@@ -0,0 +1,152 @@
+// Package config loads the synapsectl configuration file, which declares the
+// environments a package is deployed to.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is the configuration file read when no path is given.
+const DefaultPath = "synapsectl.yaml"
+
+// Version is the configuration schema version this build reads.
+const Version = 1
+
+// Environment is a deployment environment. Empty fields are unset.
+type Environment struct {
+	Workspace     string `yaml:"workspace"`
+	Subscription  string `yaml:"subscription"`
+	ResourceGroup string `yaml:"resourceGroup"`
+	// Cloud is public, china or usgovernment.
+	Cloud string `yaml:"cloud"`
+	// Auth is client-secret, default, managed-identity or azure-cli.
+	Auth     string `yaml:"auth"`
+	TenantID string `yaml:"tenantId"`
+	ClientID string `yaml:"clientId"`
+	// Parameters are parameter files applied in order before deploying.
+	Parameters []string `yaml:"parameters"`
+	// Exclude are patterns of artifacts never pruned.
+	Exclude []string `yaml:"exclude"`
+}
+
+// Merge returns e overridden by the set fields of over. Lists are appended,
+// so an environment adds parameter files and exclusions to the defaults.
+func (e Environment) Merge(over Environment) Environment {
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&e.Workspace, over.Workspace},
+		{&e.Subscription, over.Subscription},
+		{&e.ResourceGroup, over.ResourceGroup},
+		{&e.Cloud, over.Cloud},
+		{&e.Auth, over.Auth},
+		{&e.TenantID, over.TenantID},
+		{&e.ClientID, over.ClientID},
+	} {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+	e.Parameters = append(append([]string(nil), e.Parameters...), over.Parameters...)
+	e.Exclude = append(append([]string(nil), e.Exclude...), over.Exclude...)
+	return e
+}
+
+// FromEnv reads an environment from SYNAPSE_WORKSPACE, AZURE_SUBSCRIPTION_ID,
+// AZURE_RESOURCE_GROUP, AZURE_CLOUD, SYNAPSE_AUTH, AZURE_TENANT_ID and
+// AZURE_CLIENT_ID.
+func FromEnv(getenv func(string) string) Environment {
+	return Environment{
+		Workspace:     getenv("SYNAPSE_WORKSPACE"),
+		Subscription:  getenv("AZURE_SUBSCRIPTION_ID"),
+		ResourceGroup: getenv("AZURE_RESOURCE_GROUP"),
+		Cloud:         getenv("AZURE_CLOUD"),
+		Auth:          getenv("SYNAPSE_AUTH"),
+		TenantID:      getenv("AZURE_TENANT_ID"),
+		ClientID:      getenv("AZURE_CLIENT_ID"),
+	}
+}
+
+// File is a configuration file shaped like:
+//
+//	version: 1
+//	defaults:
+//	  cloud: public
+//	  auth: client-secret
+//	environments:
+//	  prod:
+//	    workspace: synawsp-prod
+//	    subscription: 00000000-0000-0000-0000-000000000000
+//	    resourceGroup: rg-synapse-prod
+//	    parameters: [parameters/prod.yaml]
+//	    exclude: [notebook/Scratch*]
+type File struct {
+	Version      int                    `yaml:"version"`
+	Defaults     Environment            `yaml:"defaults"`
+	Environments map[string]Environment `yaml:"environments"`
+
+	// dir is the directory of the file, which relative parameter file
+	// paths are resolved against.
+	dir string
+}
+
+// Load reads a configuration file. Unknown keys are errors, so typos do not
+// silently fall back to defaults.
+func Load(filePath string) (*File, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	f := &File{dir: filepath.Dir(filePath)}
+	if err := dec.Decode(f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", filePath, err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("config file %s has version %d, expected %d", filePath, f.Version, Version)
+	}
+	return f, nil
+}
+
+// Environment returns the named environment layered over the defaults, or
+// the defaults alone when name is empty.
+func (f *File) Environment(name string) (Environment, error) {
+	e := Environment{}.Merge(f.Defaults)
+	if name != "" {
+		env, ok := f.Environments[name]
+		if !ok {
+			return Environment{}, fmt.Errorf("unknown environment %q, have: %s", name, strings.Join(f.Names(), ", "))
+		}
+		e = e.Merge(env)
+	}
+
+	for i, p := range e.Parameters {
+		if !filepath.IsAbs(p) {
+			e.Parameters[i] = filepath.Join(f.dir, p)
+		}
+	}
+	return e, nil
+}
+
+// Names returns the sorted environment names.
+func (f *File) Names() []string {
+	names := make([]string, 0, len(f.Environments))
+	for name := range f.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := Environment{
+		Workspace:  "ws-dev",
+		Cloud:      "public",
+		Auth:       "client-secret",
+		TenantID:   "tenant",
+		Parameters: []string{"common.yaml"},
+		Exclude:    []string{"Scratch*"},
+	}
+
+	tests := []struct {
+		name string
+		over Environment
+		want Environment
+	}{
+		{name: "empty override", over: Environment{}, want: base},
+		{
+			name: "set fields replace",
+			over: Environment{Workspace: "ws-prod", Subscription: "sub", ResourceGroup: "rg", Cloud: "china", Auth: "azure-cli", TenantID: "other", ClientID: "client"},
+			want: Environment{
+				Workspace: "ws-prod", Subscription: "sub", ResourceGroup: "rg", Cloud: "china", Auth: "azure-cli", TenantID: "other", ClientID: "client",
+				Parameters: []string{"common.yaml"},
+				Exclude:    []string{"Scratch*"},
+			},
+		},
+		{
+			name: "lists append",
+			over: Environment{Parameters: []string{"prod.yaml"}, Exclude: []string{"notebook/Tmp*"}},
+			want: Environment{
+				Workspace: "ws-dev", Cloud: "public", Auth: "client-secret", TenantID: "tenant",
+				Parameters: []string{"common.yaml", "prod.yaml"},
+				Exclude:    []string{"Scratch*", "notebook/Tmp*"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := base.Merge(tt.over)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+
+	// merging must not write into the lists of the receiver
+	shared := Environment{Parameters: make([]string, 1, 4)}
+	a := shared.Merge(Environment{Parameters: []string{"a.yaml"}})
+	b := shared.Merge(Environment{Parameters: []string{"b.yaml"}})
+	if a.Parameters[1] != "a.yaml" || b.Parameters[1] != "b.yaml" {
+		t.Errorf("merges share a backing array: %v, %v", a.Parameters, b.Parameters)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	vars := map[string]string{
+		"SYNAPSE_WORKSPACE":     "ws",
+		"AZURE_SUBSCRIPTION_ID": "sub",
+		"AZURE_RESOURCE_GROUP":  "rg",
+		"AZURE_CLOUD":           "china",
+		"SYNAPSE_AUTH":          "default",
+		"AZURE_TENANT_ID":       "tenant",
+		"AZURE_CLIENT_ID":       "client",
+	}
+	got := FromEnv(func(key string) string { return vars[key] })
+	want := Environment{Workspace: "ws", Subscription: "sub", ResourceGroup: "rg", Cloud: "china", Auth: "default", TenantID: "tenant", ClientID: "client"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromEnv = %+v, want %+v", got, want)
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "conf", "synapsectl.yaml")
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestEnvironment(t *testing.T) {
+	abs := filepath.Join(t.TempDir(), "shared.yaml")
+	p := writeConfig(t, `
+version: 1
+defaults:
+  cloud: public
+  auth: client-secret
+  parameters: [parameters/common.yaml]
+  exclude: [Scratch*]
+environments:
+  dev:
+    workspace: ws-dev
+  prod:
+    workspace: ws-prod
+    auth: managed-identity
+    parameters: [parameters/prod.yaml, `+abs+`]
+`)
+	f, err := Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Dir(p)
+
+	tests := []struct {
+		env     string
+		want    Environment
+		wantErr string
+	}{
+		{
+			env: "",
+			want: Environment{
+				Cloud: "public", Auth: "client-secret",
+				Parameters: []string{filepath.Join(dir, "parameters", "common.yaml")},
+				Exclude:    []string{"Scratch*"},
+			},
+		},
+		{
+			env: "prod",
+			want: Environment{
+				Workspace: "ws-prod", Cloud: "public", Auth: "managed-identity",
+				Parameters: []string{filepath.Join(dir, "parameters", "common.yaml"), filepath.Join(dir, "parameters", "prod.yaml"), abs},
+				Exclude:    []string{"Scratch*"},
+			},
+		},
+		{env: "test", wantErr: `unknown environment "test", have: dev, prod`},
+	}
+	for _, tt := range tests {
+		got, err := f.Environment(tt.env)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Environment(%q) error = %v, want %q", tt.env, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Environment(%q): %v", tt.env, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Environment(%q) =\n%+v\nwant\n%+v", tt.env, got, tt.want)
+		}
+	}
+
+	// resolving paths must not change the file's own lists
+	if again, _ := f.Environment(""); again.Parameters[0] != filepath.Join(dir, "parameters", "common.yaml") {
+		t.Errorf("second Environment call resolved %v", again.Parameters)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown key", content: "version: 1\ndefaults:\n  workpsace: ws\n", wantErr: "field workpsace not found"},
+		{name: "wrong version", content: "version: 2\n", wantErr: "has version 2, expected 1"},
+		{name: "empty", content: "", wantErr: "has version 0, expected 1"},
+		{name: "not yaml", content: "version: [\n", wantErr: "failed to parse config file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
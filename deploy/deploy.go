@@ -0,0 +1,135 @@
+// Package deploy publishes the artifacts of a package to a target level by
+// level along the dependency graph, publishing the artifacts of one level
+// concurrently.
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/graph"
+	"github.com/utsavudhungana/artifactsrepo/target"
+)
+
+// Options controls a deploy run.
+type Options struct {
+	// Parallelism is the most artifacts published at once. Values below 1
+	// publish one artifact at a time.
+	Parallelism int
+	// ContinueOnError keeps publishing after a failure. Artifacts that
+	// depend on a failed artifact are skipped, and all failures are
+	// returned together at the end.
+	ContinueOnError bool
+}
+
+// Publish publishes the artifacts of g to dest. Each dependency level is
+// published concurrently and finishes before the next level starts.
+// Read-only artifacts, such as integration runtimes, only satisfy the
+// references of others and are skipped, as are the workspace default linked
+// services, which belong to the workspace. Without ContinueOnError the first
+// failure cancels the artifacts still waiting and is returned once the
+// in-flight ones are done.
+func Publish(ctx context.Context, dest target.ArtifactTarget, g *graph.Graph, opts Options) error {
+	levels, err := g.Levels()
+	if err != nil {
+		return err
+	}
+
+	parallelism := opts.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var (
+		mu     sync.Mutex
+		failed = make(map[string]bool)
+		errs   []error
+	)
+
+	for _, level := range levels {
+		group, groupCtx := errgroup.WithContext(ctx)
+		group.SetLimit(parallelism)
+
+		for _, a := range level {
+			if artifact.IsReadOnly(a.Type) {
+				fmt.Printf("Skipped artifact: %s (type: %s), read-only in the workspace\n", a.Name, a.Type)
+				continue
+			}
+			if artifact.IsWorkspaceDefault(a) {
+				fmt.Printf("Skipped artifact: %s (type: %s), workspace default linked service\n", a.Name, a.Type)
+				continue
+			}
+
+			mu.Lock()
+			dep := failedDependency(g, a, failed)
+			if dep != "" {
+				failed[a.Key()] = true
+			}
+			mu.Unlock()
+			if dep != "" {
+				fmt.Printf("Skipped artifact: %s (type: %s), dependency %s failed\n", a.Name, a.Type, dep)
+				continue
+			}
+
+			if groupCtx.Err() != nil {
+				break
+			}
+
+			a := a
+			group.Go(func() error {
+				// A failure may have canceled the level while a waited
+				// for a free slot
+				if err := groupCtx.Err(); err != nil {
+					return err
+				}
+				err := publish(groupCtx, dest, a)
+				if err == nil {
+					return nil
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				failed[a.Key()] = true
+				errs = append(errs, err)
+				if opts.ContinueOnError {
+					fmt.Printf("Failed to publish artifact: %s (type: %s): %v\n", a.Name, a.Type, err)
+					return nil
+				}
+				return err
+			})
+		}
+
+		if err := group.Wait(); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func publish(ctx context.Context, dest target.ArtifactTarget, a artifact.Artifact) error {
+	if err := dest.Publish(ctx, a); err != nil {
+		return fmt.Errorf("failed to publish artifact %s: %v", a.Key(), err)
+	}
+	fmt.Printf("Successfully published artifact: %s (type: %s)\n", a.Name, a.Type)
+	return nil
+}
+
+// failedDependency returns the key of a dependency of a that failed or was
+// skipped, or "" when there is none.
+func failedDependency(g *graph.Graph, a artifact.Artifact, failed map[string]bool) string {
+	for _, dep := range g.Dependencies(a.Key()) {
+		if failed[dep] {
+			return dep
+		}
+	}
+	return ""
+}
@@ -0,0 +1,195 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/graph"
+	"github.com/utsavudhungana/artifactsrepo/internal/artifacttest"
+)
+
+// recorder is a target.ArtifactTarget that records what it publishes, in
+// order, and the most publishes it saw in flight at once. Publishing an
+// artifact listed in fail returns an error, and every publish takes delay.
+type recorder struct {
+	fail  map[string]bool
+	delay time.Duration
+
+	mu          sync.Mutex
+	published   []string
+	inFlight    int
+	maxInFlight int
+}
+
+func (r *recorder) Publish(ctx context.Context, a artifact.Artifact) error {
+	r.mu.Lock()
+	r.inFlight++
+	r.maxInFlight = max(r.maxInFlight, r.inFlight)
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.inFlight--
+		r.mu.Unlock()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(r.delay):
+	}
+	if r.fail[a.Key()] {
+		return errors.New("rejected")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.published = append(r.published, a.Key())
+	return nil
+}
+
+func (r *recorder) Close() error   { return nil }
+func (r *recorder) String() string { return "recorder" }
+
+func build(t *testing.T, artifacts ...artifact.Artifact) *graph.Graph {
+	t.Helper()
+	g, err := graph.Build(artifacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestPublishSkipsWorkspaceOwned(t *testing.T) {
+	g := build(t,
+		artifacttest.Def(t, artifact.IntegrationRuntime, "AutoResolveIntegrationRuntime"),
+		artifacttest.Def(t, artifact.LinkedService, "synws-dev-WorkspaceDefaultStorage", "IntegrationRuntimeReference/AutoResolveIntegrationRuntime"),
+		artifacttest.Def(t, artifact.LinkedService, "synws-dev-WorkspaceDefaultSqlServer"),
+		artifacttest.Def(t, artifact.LinkedService, "ls_lake", "IntegrationRuntimeReference/AutoResolveIntegrationRuntime"),
+		artifacttest.Def(t, artifact.Dataset, "ds_raw", "LinkedServiceReference/synws-dev-WorkspaceDefaultStorage"),
+	)
+
+	dest := &recorder{}
+	if err := Publish(context.Background(), dest, g, Options{Parallelism: 2}); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(dest.published)
+	if want := []string{"dataset/ds_raw", "linkedService/ls_lake"}; !reflect.DeepEqual(dest.published, want) {
+		t.Errorf("published %v, want %v", dest.published, want)
+	}
+}
+
+// chain returns a graph of three levels: two notebooks, two pipelines
+// running one notebook each, and a pipeline running both pipelines.
+func chain(t *testing.T) *graph.Graph {
+	return build(t,
+		artifacttest.Def(t, artifact.Notebook, "nb1"),
+		artifacttest.Def(t, artifact.Notebook, "nb2"),
+		artifacttest.Def(t, artifact.Pipeline, "pl1", "NotebookReference/nb1"),
+		artifacttest.Def(t, artifact.Pipeline, "pl2", "NotebookReference/nb2"),
+		artifacttest.Def(t, artifact.Pipeline, "main", "PipelineReference/pl1", "PipelineReference/pl2"),
+	)
+}
+
+func TestPublishLevelOrder(t *testing.T) {
+	dest := &recorder{delay: time.Millisecond}
+	if err := Publish(context.Background(), dest, chain(t), Options{Parallelism: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	position := make(map[string]int)
+	for i, key := range dest.published {
+		position[key] = i
+	}
+	if len(position) != 5 {
+		t.Fatalf("published %v, want all five artifacts", dest.published)
+	}
+	for _, edge := range [][2]string{
+		{"notebook/nb1", "pipeline/pl1"},
+		{"notebook/nb2", "pipeline/pl2"},
+		// a level finishes before the next one starts
+		{"notebook/nb2", "pipeline/pl1"},
+		{"pipeline/pl1", "pipeline/main"},
+		{"pipeline/pl2", "pipeline/main"},
+	} {
+		if position[edge[0]] > position[edge[1]] {
+			t.Errorf("published %v: %s before %s", dest.published, edge[1], edge[0])
+		}
+	}
+	if dest.maxInFlight != 2 {
+		t.Errorf("published up to %d artifacts at once, want the 2 of a level", dest.maxInFlight)
+	}
+}
+
+func TestPublishParallelism(t *testing.T) {
+	var artifacts []artifact.Artifact
+	for i := 0; i < 8; i++ {
+		artifacts = append(artifacts, artifacttest.Def(t, artifact.Notebook, fmt.Sprintf("nb%d", i)))
+	}
+	g := build(t, artifacts...)
+
+	for _, tt := range []struct {
+		parallelism int
+		want        int
+	}{
+		{parallelism: 0, want: 1},
+		{parallelism: 1, want: 1},
+		{parallelism: 3, want: 3},
+		{parallelism: 20, want: 8},
+	} {
+		dest := &recorder{delay: 10 * time.Millisecond}
+		if err := Publish(context.Background(), dest, g, Options{Parallelism: tt.parallelism}); err != nil {
+			t.Fatal(err)
+		}
+		if len(dest.published) != 8 {
+			t.Errorf("parallelism %d: published %d artifacts, want 8", tt.parallelism, len(dest.published))
+		}
+		if dest.maxInFlight != tt.want {
+			t.Errorf("parallelism %d: published up to %d artifacts at once, want %d", tt.parallelism, dest.maxInFlight, tt.want)
+		}
+	}
+}
+
+func TestPublishStopsOnFirstFailure(t *testing.T) {
+	dest := &recorder{fail: map[string]bool{"notebook/nb1": true}}
+	err := Publish(context.Background(), dest, chain(t), Options{Parallelism: 1})
+	if err == nil || !strings.Contains(err.Error(), "failed to publish artifact notebook/nb1: rejected") {
+		t.Fatalf("Publish error = %v, want the failure of notebook/nb1", err)
+	}
+	if len(dest.published) != 0 {
+		t.Errorf("published %v after the first failure, want nothing", dest.published)
+	}
+}
+
+func TestPublishContinueOnError(t *testing.T) {
+	dest := &recorder{fail: map[string]bool{"notebook/nb1": true}}
+	err := Publish(context.Background(), dest, chain(t), Options{Parallelism: 2, ContinueOnError: true})
+	if err == nil || !strings.Contains(err.Error(), "failed to publish artifact notebook/nb1: rejected") {
+		t.Fatalf("Publish error = %v, want the failure of notebook/nb1", err)
+	}
+
+	// pl1 runs the failed notebook, and main runs pl1
+	sort.Strings(dest.published)
+	if want := []string{"notebook/nb2", "pipeline/pl2"}; !reflect.DeepEqual(dest.published, want) {
+		t.Errorf("published %v, want %v", dest.published, want)
+	}
+}
+
+func TestPublishCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dest := &recorder{}
+	if err := Publish(ctx, dest, chain(t), Options{Parallelism: 2}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Publish error = %v, want %v", err, context.Canceled)
+	}
+	if len(dest.published) != 0 {
+		t.Errorf("published %v after cancellation", dest.published)
+	}
+}
@@ -0,0 +1,62 @@
+// Package export reads the artifacts of a workspace into the Synapse Git
+// layout.
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/synapse"
+	"github.com/utsavudhungana/artifactsrepo/target"
+)
+
+// Workspace lists every artifact of the given types, folder names or their
+// az CLI aliases, all known types when types is empty, and formats each
+// definition as Synapse Studio commits it.
+// A type the workspace does not offer, such as managed virtual networks in
+// a workspace without one, or that the data plane does not serve, such as
+// credentials, is skipped with a message.
+func Workspace(ctx context.Context, reader target.Reader, types []string) ([]artifact.Artifact, error) {
+	if len(types) == 0 {
+		types = artifact.Types
+	}
+
+	var artifacts []artifact.Artifact
+	for _, t := range types {
+		artifactType, err := artifact.TypeFromFolder(t)
+		if err != nil {
+			return nil, err
+		}
+
+		if !synapse.Serves(artifactType) {
+			fmt.Printf("Skipped artifact type %s: not available through the Synapse data plane\n", artifactType)
+			continue
+		}
+
+		items, err := reader.List(ctx, artifactType)
+		if errors.Is(err, target.ErrNotFound) {
+			fmt.Printf("Skipped artifact type %s: not available in the workspace\n", artifactType)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s artifacts: %v", artifactType, err)
+		}
+
+		for _, a := range items {
+			if a.Name == "" {
+				return nil, fmt.Errorf("a %s artifact has no name", artifactType)
+			}
+			content, err := artifact.Format(a.Content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to format %s: %v", a.Key(), err)
+			}
+			a.Content = content
+			artifacts = append(artifacts, a)
+		}
+	}
+
+	artifact.Sort(artifacts)
+	return artifacts, nil
+}
@@ -0,0 +1,106 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/internal/artifacttest"
+	"github.com/utsavudhungana/artifactsrepo/target"
+)
+
+func TestWorkspace(t *testing.T) {
+	w := artifacttest.NewWorkspace(
+		artifacttest.New(t, "pipeline/p1.json", `{"id":"/pipelines/p1","name":"p1","etag":"1","properties":{"activities":[],"lastPublishTime":"2026-10-16T09:30:00Z"}}`),
+		artifacttest.New(t, "notebook/nb2.json", `{"name":"nb2","properties":{}}`),
+		artifacttest.New(t, "notebook/nb1.json", `{"name":"nb1","properties":{}}`),
+		artifacttest.New(t, "linkedService/ls1.json", `{"name":"ls1","properties":{"type":"AzureBlobFS"}}`),
+	)
+	// an older workspace without Spark configurations answers 404
+	w.Errs[artifact.SparkConfiguration] = target.ErrNotFound
+
+	artifacts, err := Workspace(context.Background(), w, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"linkedService/ls1", "notebook/nb1", "notebook/nb2", "pipeline/p1"}
+	if got := artifacttest.Keys(artifacts); !reflect.DeepEqual(got, want) {
+		t.Errorf("Workspace = %v, want %v", got, want)
+	}
+	for _, listed := range w.Listed() {
+		if listed == artifact.Credential {
+			t.Error("listed credentials, which the data plane does not serve")
+		}
+	}
+
+	p1 := artifacts[3]
+	if want := "{\n\t\"name\": \"p1\",\n\t\"properties\": {\n\t\t\"activities\": []\n\t}\n}"; string(p1.Content) != want {
+		t.Errorf("p1 is written as\n%s\nwant\n%s", p1.Content, want)
+	}
+}
+
+func TestWorkspaceTypes(t *testing.T) {
+	w := artifacttest.NewWorkspace(
+		artifacttest.New(t, "notebook/nb1.json", `{"name":"nb1"}`),
+		artifacttest.New(t, "linkedService/ls1.json", `{"name":"ls1"}`),
+	)
+
+	// az CLI folder names select the same types
+	artifacts, err := Workspace(context.Background(), w, []string{"linked-service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := artifacttest.Keys(artifacts); !reflect.DeepEqual(got, []string{"linkedService/ls1"}) {
+		t.Errorf("Workspace(linked-service) = %v, want linkedService/ls1", got)
+	}
+	if got := w.Listed(); !reflect.DeepEqual(got, []string{artifact.LinkedService}) {
+		t.Errorf("listed %v, want linkedService only", got)
+	}
+
+	if _, err := Workspace(context.Background(), w, []string{"notebooks"}); err == nil {
+		t.Error("exported an unknown type")
+	}
+}
+
+func TestWorkspaceErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		held    artifact.Artifact
+		listErr error
+		want    string
+	}{
+		{
+			name:    "list fails",
+			listErr: errors.New("GET /notebooks returned 403"),
+			want:    "failed to list notebook artifacts: GET /notebooks returned 403",
+		},
+		{
+			name: "no name",
+			held: artifact.Artifact{Type: artifact.Notebook, Content: []byte(`{}`)},
+			want: "a notebook artifact has no name",
+		},
+		{
+			name: "not an object",
+			held: artifact.Artifact{Type: artifact.Notebook, Name: "nb1", Content: []byte(`[]`)},
+			want: "failed to format notebook/nb1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := artifacttest.NewWorkspace()
+			if tt.held.Type != "" {
+				w = artifacttest.NewWorkspace(tt.held)
+			}
+			w.Errs[artifact.Notebook] = tt.listErr
+
+			_, err := Workspace(context.Background(), w, []string{artifact.Notebook})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Workspace error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Package gitlab reads Synapse artifact definitions straight from a GitLab
+// repository.
+package gitlab
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/httpclient"
+	gogitlab "github.com/xanzy/go-gitlab"
+)
+
+// DefaultURL is the GitLab instance used when no base URL is configured.
+const DefaultURL = "https://gitlab.com"
+
+// Options configure a Client.
+type Options struct {
+	// BaseURL is the GitLab instance, DefaultURL when empty.
+	BaseURL string
+	// CABundle is a PEM file of extra certificate authorities to trust, for
+	// self-managed instances with a private CA.
+	CABundle     string
+	PrivateToken string
+	ProjectID    int
+	// Ref is a branch, tag or commit.
+	Ref string
+	// Root is the repository directory holding the artifact folders, such
+	// as synapse/; empty means the repository root.
+	Root string
+}
+
+// Client reads files below a directory of one GitLab project at a fixed ref.
+type Client struct {
+	api       *gogitlab.Client
+	baseURL   string
+	projectID int
+	ref       string
+	root      string
+}
+
+// NewClient creates a client for a project at a branch, tag or commit.
+func NewClient(opts Options) (*Client, error) {
+	baseURL := strings.TrimSuffix(opts.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+
+	httpClient := httpclient.Default()
+	if opts.CABundle != "" {
+		var err error
+		httpClient, err = httpclient.WithCABundle(httpClient, opts.CABundle)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	api, err := gogitlab.NewClient(opts.PrivateToken,
+		gogitlab.WithBaseURL(baseURL),
+		gogitlab.WithHTTPClient(httpClient),
+		gogitlab.WithoutRetries(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitLab client: %v", err)
+	}
+
+	return &Client{
+		api:       api,
+		baseURL:   baseURL,
+		projectID: opts.ProjectID,
+		ref:       opts.Ref,
+		root:      strings.Trim(path.Clean("/"+opts.Root), "/"),
+	}, nil
+}
+
+// String describes the project, ref and root for log lines.
+func (c *Client) String() string {
+	s := fmt.Sprintf("%s/projects/%d@%s", c.baseURL, c.projectID, c.ref)
+	if c.root != "" {
+		s += ":" + c.root
+	}
+	return s
+}
+
+// treePageSize is the number of tree entries requested per page, the most
+// GitLab returns.
+const treePageSize = 100
+
+// ListFiles returns the paths, relative to the root, of every file below
+// the root. It walks the tree recursively and follows every page, with
+// keyset pagination or, on instances without it, page numbers.
+func (c *Client) ListFiles() ([]string, error) {
+	opts := &gogitlab.ListTreeOptions{
+		ListOptions: gogitlab.ListOptions{Pagination: "keyset", PerPage: treePageSize},
+		Ref:         gogitlab.String(c.ref),
+		Recursive:   gogitlab.Bool(true),
+	}
+	if c.root != "" {
+		opts.Path = gogitlab.String(c.root)
+	}
+
+	var filePaths []string
+	var pageOptions []gogitlab.RequestOptionFunc
+	for {
+		nodes, resp, err := c.api.Repositories.ListTree(c.projectID, opts, pageOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list directory tree: %v", err)
+		}
+
+		for _, node := range nodes {
+			if node.Type != "blob" {
+				continue
+			}
+			rel := node.Path
+			if c.root != "" {
+				rel = strings.TrimPrefix(rel, c.root+"/")
+			}
+			filePaths = append(filePaths, rel)
+		}
+
+		switch {
+		case resp.NextLink != "":
+			pageOptions = []gogitlab.RequestOptionFunc{gogitlab.WithKeysetPaginationParameters(resp.NextLink)}
+		case resp.NextPage > 0:
+			opts.Page = resp.NextPage
+		default:
+			return filePaths, nil
+		}
+	}
+}
+
+// GetFile returns the content of a file, given relative to the root.
+func (c *Client) GetFile(filePath string) ([]byte, error) {
+	content, _, err := c.api.RepositoryFiles.GetRawFile(c.projectID, path.Join(c.root, filePath), &gogitlab.GetRawFileOptions{Ref: gogitlab.String(c.ref)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file content: %v", err)
+	}
+
+	return content, nil
+}
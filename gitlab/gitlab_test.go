@@ -0,0 +1,233 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/httpclient"
+)
+
+type treeNode struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// server is a fake GitLab API for project 42. tree holds the pages of the
+// repository tree; keyset selects keyset pagination over page numbers.
+type server struct {
+	tree   [][]treeNode
+	keyset bool
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.queries = append(s.queries, r.URL.RawQuery)
+	s.mu.Unlock()
+
+	if r.Header.Get("Private-Token") != "token" {
+		http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/api/v4/projects/42/repository/tree":
+		q := r.URL.Query()
+		page := 0
+		if s.keyset && q.Get("pagination") == "keyset" {
+			fmt.Sscan(q.Get("page_token"), &page)
+			if page+1 < len(s.tree) {
+				next := *r.URL
+				next.Scheme, next.Host = "https", r.Host
+				values := next.Query()
+				values.Set("page_token", fmt.Sprint(page+1))
+				next.RawQuery = values.Encode()
+				w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+			}
+		} else {
+			if p := q.Get("page"); p != "" {
+				fmt.Sscan(p, &page)
+				page--
+			}
+			if page+1 < len(s.tree) {
+				w.Header().Set("X-Next-Page", fmt.Sprint(page+2))
+			}
+		}
+		json.NewEncoder(w).Encode(s.tree[page])
+	case strings.HasPrefix(r.URL.Path, "/api/v4/projects/42/repository/files/"):
+		file := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v4/projects/42/repository/files/"), "/raw")
+		fmt.Fprintf(w, "%s@%s", file, r.URL.Query().Get("ref"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newServer starts a TLS server and returns it with a PEM file of its
+// certificate, which the shared client does not trust.
+func newServer(t *testing.T, s *server) (*httptest.Server, string) {
+	t.Helper()
+	srv := httptest.NewTLSServer(s)
+	t.Cleanup(srv.Close)
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(bundle, pemData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := httpclient.Default()
+	t.Cleanup(func() { httpclient.SetDefault(previous) })
+	opts := httpclient.DefaultOptions()
+	opts.MaxRetries = 0
+	httpclient.SetDefault(httpclient.New(opts))
+	return srv, bundle
+}
+
+var pages = [][]treeNode{
+	{
+		{Path: "synapse/linkedService", Type: "tree"},
+		{Path: "synapse/linkedService/ls.json", Type: "blob"},
+		{Path: "synapse/notebook", Type: "tree"},
+	},
+	{
+		{Path: "synapse/notebook/nb1.json", Type: "blob"},
+		{Path: "synapse/notebook/nb2.json", Type: "blob"},
+	},
+	{
+		{Path: "synapse/publish_config.json", Type: "blob"},
+	},
+}
+
+func TestListFiles(t *testing.T) {
+	want := []string{"linkedService/ls.json", "notebook/nb1.json", "notebook/nb2.json", "publish_config.json"}
+
+	for _, keyset := range []bool{true, false} {
+		t.Run(fmt.Sprintf("keyset=%v", keyset), func(t *testing.T) {
+			s := &server{tree: pages, keyset: keyset}
+			srv, bundle := newServer(t, s)
+
+			c, err := NewClient(Options{BaseURL: srv.URL + "/", CABundle: bundle, PrivateToken: "token", ProjectID: 42, Ref: "main", Root: "/synapse/"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := c.ListFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ListFiles = %v, want %v", got, want)
+			}
+
+			if len(s.queries) != len(pages) {
+				t.Fatalf("listed the tree in %d requests, want %d: %v", len(s.queries), len(pages), s.queries)
+			}
+			for i, query := range s.queries {
+				for _, param := range []string{"path=synapse", "recursive=true", "ref=main", "per_page=100"} {
+					if !strings.Contains(query, param) {
+						t.Errorf("request %d query %q lacks %s", i+1, query, param)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestListFilesRepositoryRoot(t *testing.T) {
+	s := &server{tree: [][]treeNode{{
+		{Path: "notebook", Type: "tree"},
+		{Path: "notebook/nb.json", Type: "blob"},
+		{Path: "README.md", Type: "blob"},
+	}}}
+	srv, bundle := newServer(t, s)
+
+	c, err := NewClient(Options{BaseURL: srv.URL, CABundle: bundle, PrivateToken: "token", ProjectID: 42, Ref: "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.ListFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"notebook/nb.json", "README.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListFiles = %v, want %v", got, want)
+	}
+	if strings.Contains(s.queries[0], "path=") {
+		t.Errorf("query %q names a path without a root", s.queries[0])
+	}
+}
+
+func TestGetFile(t *testing.T) {
+	srv, bundle := newServer(t, &server{})
+	c, err := NewClient(Options{BaseURL: srv.URL, CABundle: bundle, PrivateToken: "token", ProjectID: 42, Ref: "main", Root: "synapse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.GetFile("notebook/nb1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "synapse/notebook/nb1.json@main" {
+		t.Errorf("GetFile = %q, want the file below the root at main", got)
+	}
+}
+
+func TestTLS(t *testing.T) {
+	s := &server{tree: pages}
+	srv, bundle := newServer(t, s)
+
+	c, err := NewClient(Options{BaseURL: srv.URL, PrivateToken: "token", ProjectID: 42, Ref: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListFiles(); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("ListFiles without the CA bundle error = %v, want a certificate error", err)
+	}
+
+	c, err = NewClient(Options{BaseURL: srv.URL, CABundle: bundle, PrivateToken: "wrong", ProjectID: 42, Ref: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListFiles(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("ListFiles with a wrong token error = %v, want the 401", err)
+	}
+
+	if _, err := NewClient(Options{CABundle: filepath.Join(t.TempDir(), "missing.pem")}); err == nil || !strings.Contains(err.Error(), "failed to read CA bundle") {
+		t.Errorf("NewClient with a missing CA bundle error = %v", err)
+	}
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("no certificates here"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewClient(Options{CABundle: empty}); err == nil || !strings.Contains(err.Error(), "no certificates found") {
+		t.Errorf("NewClient with an empty CA bundle error = %v", err)
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, tt := range []struct {
+		opts Options
+		want string
+	}{
+		{Options{ProjectID: 42, Ref: "main"}, "https://gitlab.com/projects/42@main"},
+		{Options{BaseURL: "https://gitlab.example.com/", ProjectID: 7, Ref: "v1", Root: "synapse/"}, "https://gitlab.example.com/projects/7@v1:synapse"},
+	} {
+		c, err := NewClient(tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.String(); got != tt.want {
+			t.Errorf("String = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+)
+
+// Node states in a diagram.
+const (
+	// StatusArtifact marks an artifact that is part of the package.
+	StatusArtifact = "artifact"
+	// StatusExternal marks a workspace resource that never ships in a
+	// package, such as a Spark pool.
+	StatusExternal = "external"
+	// StatusMissing marks an artifact that is referenced but not in the
+	// package, so the deploy relies on it already existing in the workspace.
+	StatusMissing = "missing"
+)
+
+// Node is an artifact or workspace resource in a diagram.
+type Node struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// Edge is a reference from one node to another.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Diagram is the node and edge list of a graph, ready to render.
+type Diagram struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Diagram lists the artifacts of the graph, everything they reference and
+// the references between them, sorted by ID.
+func (g *Graph) Diagram() Diagram {
+	nodes := make(map[string]Node)
+	var edges []Edge
+
+	for key, a := range g.nodes {
+		nodes[key] = Node{ID: key, Type: a.Type, Name: a.Name, Status: StatusArtifact}
+	}
+	for key, refs := range g.refs {
+		for _, ref := range refs {
+			edges = append(edges, Edge{From: key, To: ref.Key()})
+			if _, ok := nodes[ref.Key()]; ok {
+				continue
+			}
+
+			status := StatusExternal
+			if _, err := artifact.TypeFromFolder(ref.Type); err == nil {
+				status = StatusMissing
+			}
+			nodes[ref.Key()] = Node{ID: ref.Key(), Type: ref.Type, Name: ref.Name, Status: status}
+		}
+	}
+
+	d := Diagram{Edges: edges}
+	for _, n := range nodes {
+		d.Nodes = append(d.Nodes, n)
+	}
+	sort.Slice(d.Nodes, func(i, j int) bool {
+		return d.Nodes[i].ID < d.Nodes[j].ID
+	})
+	sort.Slice(d.Edges, func(i, j int) bool {
+		if d.Edges[i].From != d.Edges[j].From {
+			return d.Edges[i].From < d.Edges[j].From
+		}
+		return d.Edges[i].To < d.Edges[j].To
+	})
+	return d
+}
+
+// Missing returns the nodes that are referenced but not in the package.
+func (d Diagram) Missing() []Node {
+	var missing []Node
+	for _, n := range d.Nodes {
+		if n.Status == StatusMissing {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
+
+var unsafeID = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// mermaidID turns a node into a Mermaid identifier such as
+// sparkJobDefinition.Spark_job_definition_1.
+func mermaidID(n Node) string {
+	return unsafeID.ReplaceAllString(n.Type+"."+n.Name, "_")
+}
+
+// mermaidIDs assigns every node a distinct Mermaid identifier. Nodes whose
+// names turn into the same identifier, such as "Data Flow 1" and
+// "Data_Flow_1", are numbered in diagram order so neither is merged into
+// the other.
+func mermaidIDs(nodes []Node) map[string]string {
+	count := make(map[string]int, len(nodes))
+	for _, n := range nodes {
+		count[mermaidID(n)]++
+	}
+
+	ids := make(map[string]string, len(nodes))
+	taken := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		if id := mermaidID(n); count[id] == 1 {
+			ids[n.ID] = id
+			taken[id] = true
+		}
+	}
+	for _, n := range nodes {
+		if _, ok := ids[n.ID]; ok {
+			continue
+		}
+		for i := 1; ; i++ {
+			id := fmt.Sprintf("%s_%d", mermaidID(n), i)
+			if !taken[id] {
+				ids[n.ID] = id
+				taken[id] = true
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// errWriter writes to w until a write fails and keeps the first error.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
+
+// WriteMermaid renders the diagram as a Mermaid flowchart. Missing
+// artifacts are drawn dashed in red. Nodes whose identifier had to be
+// numbered are labelled with their type and name.
+func (d Diagram) WriteMermaid(w io.Writer) error {
+	ids := mermaidIDs(d.Nodes)
+	ew := &errWriter{w: w}
+
+	ew.printf("graph LR\n")
+	for _, n := range d.Nodes {
+		if ids[n.ID] != mermaidID(n) {
+			label := strings.ReplaceAll(n.Type+"."+n.Name, `"`, "#quot;")
+			ew.printf("%s[\"%s\"]\n", ids[n.ID], label)
+		}
+	}
+	linked := make(map[string]bool)
+	for _, e := range d.Edges {
+		ew.printf("%s --> %s\n", ids[e.From], ids[e.To])
+		linked[e.From], linked[e.To] = true, true
+	}
+	for _, n := range d.Nodes {
+		if !linked[n.ID] && ids[n.ID] == mermaidID(n) {
+			ew.printf("%s\n", ids[n.ID])
+		}
+	}
+
+	missing := d.Missing()
+	if len(missing) > 0 {
+		ew.printf("classDef missing stroke:#d00,stroke-width:2px,stroke-dasharray:5 5\n")
+		for _, n := range missing {
+			ew.printf("class %s missing\n", ids[n.ID])
+		}
+	}
+	return ew.err
+}
+
+// WriteDOT renders the diagram as a Graphviz digraph. Missing artifacts are
+// drawn dashed in red and external resources in grey.
+func (d Diagram) WriteDOT(w io.Writer) error {
+	ew := &errWriter{w: w}
+	ew.printf("digraph artifacts {\n")
+	ew.printf("  rankdir=LR;\n")
+	ew.printf("  node [shape=box];\n")
+	for _, n := range d.Nodes {
+		attrs := ""
+		switch n.Status {
+		case StatusMissing:
+			attrs = ", color=red, style=dashed"
+		case StatusExternal:
+			attrs = ", color=grey"
+		}
+		ew.printf("  %q [label=%q%s];\n", n.ID, n.Type+"."+n.Name, attrs)
+	}
+	for _, e := range d.Edges {
+		ew.printf("  %q -> %q;\n", e.From, e.To)
+	}
+	ew.printf("}\n")
+	return ew.err
+}
+
+// WriteJSON renders the diagram as indented JSON.
+func (d Diagram) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
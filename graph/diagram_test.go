@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/internal/artifacttest"
+)
+
+func TestWriteMermaid(t *testing.T) {
+	g, err := Build([]artifact.Artifact{
+		artifacttest.Def(t, artifact.Pipeline, "pl", "NotebookReference/Data Flow 1", "NotebookReference/Data_Flow_1", "NotebookReference/absent"),
+		artifacttest.Def(t, artifact.Notebook, "Data Flow 1"),
+		artifacttest.Def(t, artifact.Notebook, "Data_Flow_1"),
+		artifacttest.Def(t, artifact.Notebook, "Data.Flow.1"),
+		artifacttest.Def(t, artifact.Notebook, `say "hi"`),
+		artifacttest.Def(t, artifact.Notebook, "say _hi_"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := g.Diagram().WriteMermaid(&out); err != nil {
+		t.Fatal(err)
+	}
+	want := `graph LR
+notebook.Data_Flow_1_1["notebook.Data Flow 1"]
+notebook.Data_Flow_1_2["notebook.Data_Flow_1"]
+notebook.say__hi__1["notebook.say #quot;hi#quot;"]
+notebook.say__hi__2["notebook.say _hi_"]
+pipeline.pl --> notebook.Data_Flow_1_1
+pipeline.pl --> notebook.Data_Flow_1_2
+pipeline.pl --> notebook.absent
+notebook.Data.Flow.1
+classDef missing stroke:#d00,stroke-width:2px,stroke-dasharray:5 5
+class notebook.absent missing
+`
+	if out.String() != want {
+		t.Errorf("WriteMermaid =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+// failingWriter fails every write after the first n bytes.
+type failingWriter struct {
+	n int
+}
+
+var errDiskFull = errors.New("no space left on device")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+		return written, errDiskFull
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteErrors(t *testing.T) {
+	g, err := Build([]artifact.Artifact{
+		artifacttest.Def(t, artifact.Pipeline, "pl", "NotebookReference/nb", "NotebookReference/absent"),
+		artifacttest.Def(t, artifact.Notebook, "nb"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := g.Diagram()
+
+	for name, write := range map[string]func(w *failingWriter) error{
+		"mermaid": func(w *failingWriter) error { return d.WriteMermaid(w) },
+		"dot":     func(w *failingWriter) error { return d.WriteDOT(w) },
+		"json":    func(w *failingWriter) error { return d.WriteJSON(w) },
+	} {
+		for _, n := range []int{0, 20} {
+			if err := write(&failingWriter{n: n}); !errors.Is(err, errDiskFull) {
+				t.Errorf("%s failing after %d bytes: error = %v, want %v", name, n, err, errDiskFull)
+			}
+		}
+		if err := write(&failingWriter{n: 1 << 20}); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Package graph builds the dependency graph between the artifacts of a
+// package from the reference objects in their definitions, and orders
+// artifacts so every artifact is deployed after the ones it references.
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+)
+
+// Reference points from an artifact to another artifact or workspace
+// resource by type and name.
+type Reference struct {
+	Type string
+	Name string
+}
+
+// Key identifies the referenced artifact as type/name.
+func (r Reference) Key() string {
+	return r.Type + "/" + r.Name
+}
+
+// References returns the distinct references in an artifact definition. A
+// reference is any object with a string referenceName and a type ending in
+// Reference, such as:
+//
+//	{"referenceName": "Notebook2", "type": "NotebookReference"}
+func References(a artifact.Artifact) ([]Reference, error) {
+	var doc interface{}
+	if err := json.Unmarshal(a.Content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", a.Key(), err)
+	}
+
+	seen := make(map[Reference]bool)
+	var refs []Reference
+	walk(doc, func(ref Reference) {
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	})
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Key() < refs[j].Key()
+	})
+	return refs, nil
+}
+
+func walk(node interface{}, visit func(Reference)) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		name, nameOK := v["referenceName"].(string)
+		refType, typeOK := v["type"].(string)
+		if nameOK && typeOK && name != "" {
+			if t, ok := artifact.TypeFromReference(refType); ok {
+				visit(Reference{Type: t, Name: name})
+			}
+		}
+		for _, child := range v {
+			walk(child, visit)
+		}
+	case []interface{}:
+		for _, child := range v {
+			walk(child, visit)
+		}
+	}
+}
+
+// Graph holds the artifacts of a package and the references between them.
+type Graph struct {
+	nodes map[string]artifact.Artifact
+	refs  map[string][]Reference
+}
+
+// Build parses the references of every artifact. Two artifacts with the same
+// type and name are rejected, since only one of them could be deployed.
+func Build(artifacts []artifact.Artifact) (*Graph, error) {
+	g := &Graph{
+		nodes: make(map[string]artifact.Artifact, len(artifacts)),
+		refs:  make(map[string][]Reference, len(artifacts)),
+	}
+
+	for _, a := range artifacts {
+		if prev, ok := g.nodes[a.Key()]; ok {
+			return nil, fmt.Errorf("duplicate artifact %s in %s and %s", a.Key(), prev.Origin, a.Origin)
+		}
+
+		refs, err := References(a)
+		if err != nil {
+			return nil, err
+		}
+		g.nodes[a.Key()] = a
+		g.refs[a.Key()] = refs
+	}
+
+	return g, nil
+}
+
+// Dependencies returns the keys of the artifacts in the graph that the
+// artifact with the given key references. References to anything outside
+// the package are left out.
+func (g *Graph) Dependencies(key string) []string {
+	var deps []string
+	for _, ref := range g.refs[key] {
+		if _, ok := g.nodes[ref.Key()]; ok {
+			deps = append(deps, ref.Key())
+		}
+	}
+	return deps
+}
+
+// Sort returns the artifacts in dependency order: every artifact comes after
+// the artifacts it references. Artifacts that do not depend on each other are
+// ordered with artifact.Sort, so the result is stable across runs. A cycle
+// is reported with the artifacts that form it.
+func (g *Graph) Sort() ([]artifact.Artifact, error) {
+	levels, err := g.Levels()
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]artifact.Artifact, 0, len(g.nodes))
+	for _, level := range levels {
+		sorted = append(sorted, level...)
+	}
+	return sorted, nil
+}
+
+// Levels groups the artifacts into dependency levels: the first level holds
+// artifacts that reference nothing in the package, and each later level
+// holds artifacts whose references all sit in earlier levels. Artifacts of
+// one level never depend on each other, so they can be deployed
+// concurrently. Each level is ordered with artifact.Sort.
+func (g *Graph) Levels() ([][]artifact.Artifact, error) {
+	pending := make(map[string]int, len(g.nodes))
+	dependents := make(map[string][]string, len(g.nodes))
+	for key := range g.nodes {
+		deps := g.Dependencies(key)
+		pending[key] = len(deps)
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], key)
+		}
+	}
+
+	var ready []artifact.Artifact
+	for key, n := range pending {
+		if n == 0 {
+			ready = append(ready, g.nodes[key])
+		}
+	}
+
+	var levels [][]artifact.Artifact
+	for len(ready) > 0 {
+		artifact.Sort(ready)
+		levels = append(levels, ready)
+
+		var next []artifact.Artifact
+		for _, a := range ready {
+			delete(pending, a.Key())
+			for _, dependent := range dependents[a.Key()] {
+				pending[dependent]--
+				if pending[dependent] == 0 {
+					next = append(next, g.nodes[dependent])
+				}
+			}
+		}
+		ready = next
+	}
+
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("dependency cycle: %s", strings.Join(g.findCycle(pending), " -> "))
+	}
+	return levels, nil
+}
+
+// findCycle returns one cycle among the artifacts left unsorted, starting and
+// ending with the same key.
+func (g *Graph) findCycle(pending map[string]int) []string {
+	keys := make([]string, 0, len(pending))
+	for key := range pending {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Every unsorted artifact depends on another unsorted artifact, so
+	// following those dependencies must revisit a key.
+	index := make(map[string]int)
+	var path []string
+	key := keys[0]
+	for {
+		if i, ok := index[key]; ok {
+			return append(path[i:], key)
+		}
+		index[key] = len(path)
+		path = append(path, key)
+
+		for _, dep := range g.Dependencies(key) {
+			if _, ok := pending[dep]; ok {
+				key = dep
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package graph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/internal/artifacttest"
+)
+
+func TestReferences(t *testing.T) {
+	content := `{
+		"name": "pl",
+		"properties": {
+			"activities": [
+				{"type": "SynapseNotebook", "typeProperties": {
+					"notebook": {"referenceName": "nb", "type": "NotebookReference"},
+					"sparkPool": {"referenceName": "pool", "type": "BigDataPoolReference"}
+				}},
+				{"type": "Script", "linkedServiceName": {"referenceName": "ls", "type": "LinkedServiceReference"}},
+				{"type": "ExecuteDataFlow", "typeProperties": {"dataflow": {"referenceName": "df", "type": "DataFlowReference"}}},
+				{"type": "SqlPoolStoredProcedure", "sqlScript": {"referenceName": "sql", "type": "SqlScriptReference"}},
+				{"notebook": {"referenceName": "nb", "type": "NotebookReference"}},
+				{"ignored": {"referenceName": "", "type": "NotebookReference"}},
+				{"ignored": {"referenceName": "x", "type": "Expression"}}
+			]
+		}
+	}`
+	a, err := artifact.New("pipeline/pl.json", []byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := References(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ref := range refs {
+		got = append(got, ref.Key())
+	}
+	want := []string{"bigDataPool/pool", "dataflow/df", "linkedService/ls", "notebook/nb", "sqlscript/sql"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("References = %v, want %v", got, want)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	tests := []struct {
+		name      string
+		artifacts func(t *testing.T) []artifact.Artifact
+		want      [][]string
+		wantErr   string
+	}{
+		{
+			name: "empty",
+			artifacts: func(t *testing.T) []artifact.Artifact {
+				return nil
+			},
+		},
+		{
+			name: "independent artifacts share a level",
+			artifacts: func(t *testing.T) []artifact.Artifact {
+				return []artifact.Artifact{
+					artifacttest.Def(t, artifact.Pipeline, "b"),
+					artifacttest.Def(t, artifact.Notebook, "a"),
+					artifacttest.Def(t, artifact.LinkedService, "c"),
+				}
+			},
+			want: [][]string{{"linkedService/c", "notebook/a", "pipeline/b"}},
+		},
+		{
+			name: "chain",
+			artifacts: func(t *testing.T) []artifact.Artifact {
+				return []artifact.Artifact{
+					artifacttest.Def(t, artifact.Pipeline, "pl", "DatasetReference/ds"),
+					artifacttest.Def(t, artifact.Dataset, "ds", "LinkedServiceReference/ls"),
+					artifacttest.Def(t, artifact.LinkedService, "ls"),
+				}
+			},
+			want: [][]string{{"linkedService/ls"}, {"dataset/ds"}, {"pipeline/pl"}},
+		},
+		{
+			name: "diamond",
+			artifacts: func(t *testing.T) []artifact.Artifact {
+				return []artifact.Artifact{
+					artifacttest.Def(t, artifact.Pipeline, "parent", "PipelineReference/left", "PipelineReference/right"),
+					artifacttest.Def(t, artifact.Pipeline, "left", "NotebookReference/nb"),
+					artifacttest.Def(t, artifact.Pipeline, "right", "NotebookReference/nb"),
+					artifacttest.Def(t, artifact.Notebook, "nb"),
+				}
+			},
+			want: [][]string{{"notebook/nb"}, {"pipeline/left", "pipeline/right"}, {"pipeline/parent"}},
+		},
+		{
+			name: "references outside the package are ignored",
+			artifacts: func(t *testing.T) []artifact.Artifact {
+				return []artifact.Artifact{
+					artifacttest.Def(t, artifact.Notebook, "nb", "BigDataPoolReference/pool", "LinkedServiceReference/absent"),
+				}
+			},
+			want: [][]string{{"notebook/nb"}},
+		},
+		{
+			name: "self reference",
+			artifacts: func(t *testing.T) []artifact.Artifact {
+				return []artifact.Artifact{artifacttest.Def(t, artifact.Pipeline, "loop", "PipelineReference/loop")}
+			},
+			wantErr: "dependency cycle: pipeline/loop -> pipeline/loop",
+		},
+		{
+			name: "cycle",
+			artifacts: func(t *testing.T) []artifact.Artifact {
+				return []artifact.Artifact{
+					artifacttest.Def(t, artifact.Pipeline, "a", "PipelineReference/b"),
+					artifacttest.Def(t, artifact.Pipeline, "b", "PipelineReference/c"),
+					artifacttest.Def(t, artifact.Pipeline, "c", "PipelineReference/a"),
+				}
+			},
+			wantErr: "dependency cycle: pipeline/a -> pipeline/b -> pipeline/c -> pipeline/a",
+		},
+		{
+			name: "cycle behind an artifact outside it",
+			artifacts: func(t *testing.T) []artifact.Artifact {
+				return []artifact.Artifact{
+					artifacttest.Def(t, artifact.Pipeline, "a", "PipelineReference/b"),
+					artifacttest.Def(t, artifact.Pipeline, "b", "PipelineReference/c"),
+					artifacttest.Def(t, artifact.Pipeline, "c", "PipelineReference/b"),
+					artifacttest.Def(t, artifact.Notebook, "nb"),
+				}
+			},
+			wantErr: "dependency cycle: pipeline/b -> pipeline/c -> pipeline/b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := Build(tt.artifacts(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			levels, err := g.Levels()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Levels error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got [][]string
+			for _, level := range levels {
+				got = append(got, artifacttest.Keys(level))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Levels = %v, want %v", got, tt.want)
+			}
+
+			sorted, err := g.Sort()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var flat []string
+			for _, level := range tt.want {
+				flat = append(flat, level...)
+			}
+			if !reflect.DeepEqual(artifacttest.Keys(sorted), flat) {
+				t.Errorf("Sort = %v, want %v", artifacttest.Keys(sorted), flat)
+			}
+		})
+	}
+}
+
+func TestBuildDuplicate(t *testing.T) {
+	a := artifacttest.Def(t, artifact.Notebook, "nb")
+	b := a
+	b.Origin = "other/notebook/nb.json"
+	_, err := Build([]artifact.Artifact{a, b})
+	if err == nil || !strings.Contains(err.Error(), "duplicate artifact notebook/nb") {
+		t.Fatalf("Build error = %v, want a duplicate error", err)
+	}
+}
+
+func TestMissing(t *testing.T) {
+	g, err := Build([]artifact.Artifact{
+		artifacttest.Def(t, artifact.Pipeline, "pl", "NotebookReference/nb", "NotebookReference/absent", "BigDataPoolReference/pool"),
+		artifacttest.Def(t, artifact.Notebook, "nb", "LinkedServiceReference/ls"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := g.Diagram()
+	var missing []string
+	for _, n := range d.Missing() {
+		missing = append(missing, n.ID)
+	}
+	if want := []string{"linkedService/ls", "notebook/absent"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("Missing = %v, want %v", missing, want)
+	}
+
+	status := make(map[string]string)
+	for _, n := range d.Nodes {
+		status[n.ID] = n.Status
+	}
+	if status["bigDataPool/pool"] != StatusExternal {
+		t.Errorf("bigDataPool/pool status = %q, want %q", status["bigDataPool/pool"], StatusExternal)
+	}
+	if status["notebook/nb"] != StatusArtifact {
+		t.Errorf("notebook/nb status = %q, want %q", status["notebook/nb"], StatusArtifact)
+	}
+	if len(d.Edges) != 4 {
+		t.Errorf("diagram has %d edges, want 4", len(d.Edges))
+	}
+}
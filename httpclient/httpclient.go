@@ -0,0 +1,252 @@
+// Package httpclient builds the HTTP client shared by every outbound call of
+// synapsectl: Azure AD, the Synapse data plane, GitLab and OCI registries. It
+// retries throttled and failed requests with exponential backoff and jitter,
+// honours Retry-After, and only repeats requests that are safe to repeat.
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Options configures the shared client.
+type Options struct {
+	// Timeout bounds a whole call, retries included. Zero means no limit.
+	Timeout time.Duration
+	// DialTimeout bounds establishing a connection.
+	DialTimeout time.Duration
+	// ResponseHeaderTimeout bounds waiting for the response headers of a
+	// single attempt.
+	ResponseHeaderTimeout time.Duration
+	// MaxRetries is how many times a request is retried after the first
+	// attempt.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the exponential backoff between
+	// attempts.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxRetryAfter caps how long a Retry-After header can make a retry wait.
+	MaxRetryAfter time.Duration
+}
+
+// DefaultOptions returns the options used unless configured otherwise.
+func DefaultOptions() Options {
+	return Options{
+		Timeout:               5 * time.Minute,
+		DialTimeout:           30 * time.Second,
+		ResponseHeaderTimeout: 2 * time.Minute,
+		MaxRetries:            5,
+		MinBackoff:            500 * time.Millisecond,
+		MaxBackoff:            30 * time.Second,
+		MaxRetryAfter:         2 * time.Minute,
+	}
+}
+
+// New creates a client with a retrying transport.
+func New(opts Options) *http.Client {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.DialContext = (&net.Dialer{Timeout: opts.DialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	base.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
+
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: &Transport{Base: base, Options: opts},
+	}
+}
+
+var (
+	mu            sync.RWMutex
+	defaultClient = New(DefaultOptions())
+)
+
+// Default returns the shared client.
+func Default() *http.Client {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultClient
+}
+
+// SetDefault replaces the shared client. Call it before any client is
+// created from Default.
+func SetDefault(c *http.Client) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultClient = c
+}
+
+// WithCABundle returns a copy of c that also trusts the PEM certificates in
+// file, for servers with a private certificate authority.
+func WithCABundle(c *http.Client, file string) (*http.Client, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", file)
+	}
+
+	var base *http.Transport
+	retrying, ok := c.Transport.(*Transport)
+	if ok {
+		base, ok = retrying.Base.(*http.Transport)
+	} else {
+		base, ok = c.Transport.(*http.Transport)
+	}
+	if !ok {
+		return nil, fmt.Errorf("cannot set a CA bundle on a %T transport", c.Transport)
+	}
+
+	base = base.Clone()
+	if base.TLSClientConfig == nil {
+		base.TLSClientConfig = &tls.Config{}
+	}
+	base.TLSClientConfig.RootCAs = pool
+
+	client := *c
+	if retrying != nil {
+		client.Transport = &Transport{Base: base, Options: retrying.Options}
+	} else {
+		client.Transport = base
+	}
+	return &client, nil
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent marks requests made with ctx as safe to repeat even though
+// their method is not idempotent, such as a POST for an OAuth token.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// Transport retries requests sent through Base.
+type Transport struct {
+	Base    http.RoundTripper
+	Options Options
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := isIdempotent(req)
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.Base.RoundTrip(req)
+		if attempt >= t.Options.MaxRetries || !t.shouldRetry(req, resp, err, idempotent) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = min(d, t.Options.MaxRetryAfter)
+			}
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry decides whether an attempt is worth repeating. Throttling
+// (429) and 503 mean the server did not process the request, so any request
+// is retried; other server errors and network failures only when repeating
+// the request cannot apply it twice.
+func (t *Transport) shouldRetry(req *http.Request, resp *http.Response, err error, idempotent bool) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+
+	if err != nil {
+		return idempotent
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	default:
+		return false
+	}
+}
+
+// backoff returns a random delay below MinBackoff*2^attempt, capped at
+// MaxBackoff ("full jitter").
+func (t *Transport) backoff(attempt int) time.Duration {
+	ceiling := t.Options.MaxBackoff
+	if attempt < 30 {
+		if d := t.Options.MinBackoff << attempt; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling))) + 1
+}
+
+func isIdempotent(req *http.Request) bool {
+	if marked, _ := req.Context().Value(idempotentKey{}).(bool); marked {
+		return true
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		d := time.Until(when)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
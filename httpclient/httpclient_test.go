@@ -0,0 +1,246 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// server answers with statuses in order, repeating the last one, and records
+// the body of every attempt. A zero status drops the connection instead.
+type server struct {
+	mu       sync.Mutex
+	statuses []int
+	header   http.Header
+	bodies   []string
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.bodies = append(s.bodies, string(body))
+	status := s.statuses[0]
+	if len(s.statuses) > 1 {
+		s.statuses = s.statuses[1:]
+	}
+	s.mu.Unlock()
+
+	if status == 0 {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+		return
+	}
+	for k, v := range s.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(status)
+}
+
+func (s *server) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func testOptions() Options {
+	return Options{
+		MaxRetries:    3,
+		MinBackoff:    time.Millisecond,
+		MaxBackoff:    2 * time.Millisecond,
+		MaxRetryAfter: time.Minute,
+	}
+}
+
+func do(t *testing.T, c *http.Client, ctx context.Context, method, url, body string) int {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		idempotent   bool
+		statuses     []int
+		wantStatus   int
+		wantAttempts int
+	}{
+		{name: "throttled post", method: http.MethodPost, statuses: []int{429, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "unavailable post", method: http.MethodPost, statuses: []int{503, 503, 200}, wantStatus: 200, wantAttempts: 3},
+		{name: "server error get", method: http.MethodGet, statuses: []int{500, 502, 504, 200}, wantStatus: 200, wantAttempts: 4},
+		{name: "server error put", method: http.MethodPut, statuses: []int{500, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "server error delete", method: http.MethodDelete, statuses: []int{408, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "server error post", method: http.MethodPost, statuses: []int{500, 200}, wantStatus: 500, wantAttempts: 1},
+		{name: "server error patch", method: http.MethodPatch, statuses: []int{502, 200}, wantStatus: 502, wantAttempts: 1},
+		{name: "server error post marked idempotent", method: http.MethodPost, idempotent: true, statuses: []int{500, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "dropped get", method: http.MethodGet, statuses: []int{0, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "dropped post", method: http.MethodPost, statuses: []int{0, 200}, wantStatus: 0, wantAttempts: 1},
+		{name: "dropped post marked idempotent", method: http.MethodPost, idempotent: true, statuses: []int{0, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "client error", method: http.MethodGet, statuses: []int{404, 200}, wantStatus: 404, wantAttempts: 1},
+		{name: "retries exhausted", method: http.MethodGet, statuses: []int{503}, wantStatus: 503, wantAttempts: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &server{statuses: tt.statuses}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+
+			ctx := context.Background()
+			if tt.idempotent {
+				ctx = WithIdempotent(ctx)
+			}
+			status := do(t, New(testOptions()), ctx, tt.method, srv.URL, "payload")
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if got := s.attempts(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryRewindsBody(t *testing.T) {
+	s := &server{statuses: []int{429, 503, 200}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	if status := do(t, New(testOptions()), context.Background(), http.MethodPost, srv.URL, "grant_type=client_credentials"); status != 200 {
+		t.Fatalf("status = %d", status)
+	}
+	for i, body := range s.bodies {
+		if body != "grant_type=client_credentials" {
+			t.Errorf("attempt %d sent body %q", i+1, body)
+		}
+	}
+}
+
+func TestNoRetryWithoutGetBody(t *testing.T) {
+	s := &server{statuses: []int{503, 200}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	// A body NewRequest does not know how to rewind
+	req, err := http.NewRequest(http.MethodPut, srv.URL, io.MultiReader(strings.NewReader("payload")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := New(testOptions()).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 503 || s.attempts() != 1 {
+		t.Errorf("status = %d after %d attempts, want 503 after 1", resp.StatusCode, s.attempts())
+	}
+}
+
+func TestRetryAfterOverridesBackoff(t *testing.T) {
+	s := &server{statuses: []int{429, 200}, header: http.Header{"Retry-After": {"0"}}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	// Backoff alone would wait up to an hour
+	opts := testOptions()
+	opts.MinBackoff = time.Hour
+	opts.MaxBackoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if status := do(t, New(opts), ctx, http.MethodGet, srv.URL, ""); status != 200 {
+		t.Fatalf("status = %d, want 200", status)
+	}
+}
+
+func TestMaxRetryAfter(t *testing.T) {
+	s := &server{statuses: []int{429, 200}, header: http.Header{"Retry-After": {"3600"}}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	opts := testOptions()
+	opts.MaxRetryAfter = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if status := do(t, New(opts), ctx, http.MethodGet, srv.URL, ""); status != 200 {
+		t.Fatalf("status = %d, want 200", status)
+	}
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	s := &server{statuses: []int{503}, header: http.Header{"Retry-After": {"3600"}}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if status := do(t, New(testOptions()), ctx, http.MethodGet, srv.URL, ""); status != 0 {
+		t.Errorf("status = %d, want the request to fail", status)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned after %v, want soon after cancellation", elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		value string
+		ok    bool
+		min   time.Duration
+		max   time.Duration
+	}{
+		{value: ""},
+		{value: "soon"},
+		{value: "-5"},
+		{value: "0", ok: true},
+		{value: "120", ok: true, min: 2 * time.Minute, max: 2 * time.Minute},
+		{value: future, ok: true, min: 58 * time.Minute, max: time.Hour},
+		{value: past, ok: true},
+	}
+	for _, tt := range tests {
+		d, ok := parseRetryAfter(tt.value)
+		if ok != tt.ok || d < tt.min || d > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v..%v, %v", tt.value, d, ok, tt.min, tt.max, tt.ok)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tr := &Transport{Options: Options{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}}
+	for attempt, ceiling := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for i := 0; i < 50; i++ {
+			if d := tr.backoff(attempt); d <= 0 || d > ceiling {
+				t.Fatalf("backoff(%d) = %v, want within (0, %v]", attempt, d, ceiling)
+			}
+		}
+	}
+	if d := tr.backoff(100); d <= 0 || d > time.Second {
+		t.Errorf("backoff(100) = %v, want within (0, 1s]", d)
+	}
+}
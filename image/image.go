@@ -0,0 +1,181 @@
+// Package image reads the artifact directory that push_dir.sh wraps in a
+// busybox container image, from the image layers or from a docker save
+// archive, without a Docker daemon.
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// DefaultRoot is the image directory push_dir.sh copies the artifacts to.
+const DefaultRoot = "/data"
+
+// Docker media types of container images.
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeDockerConfig       = "application/vnd.docker.container.image.v1+json"
+)
+
+// Whiteout markers of the OCI and Docker layer formats.
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// IsConfig reports whether mediaType is the config of a container image.
+func IsConfig(mediaType string) bool {
+	return mediaType == MediaTypeDockerConfig || mediaType == ocispec.MediaTypeImageConfig
+}
+
+// Filesystem holds the regular files below a root directory of an image,
+// built by applying its layers in order.
+type Filesystem struct {
+	prefix string
+	files  map[string][]byte
+}
+
+// NewFilesystem returns an empty filesystem keeping the files below root,
+// such as /data.
+func NewFilesystem(root string) *Filesystem {
+	prefix := strings.TrimPrefix(path.Clean("/"+root), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &Filesystem{prefix: prefix, files: make(map[string][]byte)}
+}
+
+// Apply applies a layer, an uncompressed or gzip compressed tar, honoring
+// whiteout and opaque directory markers.
+func (f *Filesystem) Apply(layer []byte) error {
+	var r io.Reader = bytes.NewReader(layer)
+	switch {
+	case bytes.HasPrefix(layer, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("failed to create GZIP reader: %v", err)
+		}
+		defer gz.Close()
+		r = gz
+	case bytes.HasPrefix(layer, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return fmt.Errorf("zstd compressed layers are not supported")
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read layer entry: %v", err)
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+header.Name), "/")
+		dir, base := path.Split(name)
+		switch {
+		case base == whiteoutOpaque:
+			f.remove(strings.TrimSuffix(dir, "/"), false)
+			continue
+		case strings.HasPrefix(base, whiteoutPrefix):
+			f.remove(dir+strings.TrimPrefix(base, whiteoutPrefix), true)
+			continue
+		}
+
+		// A file replaces whatever the lower layers held at its path, while
+		// directories merge with theirs
+		if header.Typeflag != tar.TypeDir {
+			f.remove(name, true)
+		}
+		if !strings.HasPrefix(name, f.prefix) {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			content, err := io.ReadAll(tr)
+			if err != nil {
+				return fmt.Errorf("failed to read %s from layer: %v", header.Name, err)
+			}
+			f.files[name] = content
+		case tar.TypeLink:
+			target := strings.TrimPrefix(path.Clean("/"+header.Linkname), "/")
+			if content, ok := f.files[target]; ok {
+				f.files[name] = content
+			}
+		}
+	}
+}
+
+// remove deletes the files below dir, and dir itself when self is set.
+func (f *Filesystem) remove(dir string, self bool) {
+	if self {
+		delete(f.files, dir)
+	}
+	prefix := dir + "/"
+	if dir == "" {
+		prefix = ""
+	}
+	for name := range f.files {
+		if strings.HasPrefix(name, prefix) {
+			delete(f.files, name)
+		}
+	}
+}
+
+// Files returns the files below the root, keyed by their path relative to
+// it.
+func (f *Filesystem) Files() map[string][]byte {
+	files := make(map[string][]byte, len(f.files))
+	for name, content := range f.files {
+		files[strings.TrimPrefix(name, f.prefix)] = content
+	}
+	return files
+}
+
+// archiveManifest is an entry of the manifest.json of a docker save archive.
+type archiveManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// IsDockerArchive reports whether the files of a tar archive, keyed by entry
+// name, are a docker save archive.
+func IsDockerArchive(entries map[string][]byte) bool {
+	var images []archiveManifest
+	return json.Unmarshal(entries["manifest.json"], &images) == nil && len(images) > 0
+}
+
+// FromDockerArchive returns the files below root of the single image in a
+// docker save archive, given as its tar entries keyed by name.
+func FromDockerArchive(entries map[string][]byte, root string) (map[string][]byte, error) {
+	var images []archiveManifest
+	if err := json.Unmarshal(entries["manifest.json"], &images); err != nil {
+		return nil, fmt.Errorf("failed to parse docker archive manifest: %v", err)
+	}
+	if len(images) != 1 {
+		return nil, fmt.Errorf("docker archive holds %d images, want 1", len(images))
+	}
+
+	fs := NewFilesystem(root)
+	for _, name := range images[0].Layers {
+		layer, ok := entries[name]
+		if !ok {
+			return nil, fmt.Errorf("docker archive lacks layer %s", name)
+		}
+		if err := fs.Apply(layer); err != nil {
+			return nil, fmt.Errorf("layer %s: %v", name, err)
+		}
+	}
+	return fs.Files(), nil
+}
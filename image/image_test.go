@@ -0,0 +1,281 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// entry is a tar entry of a test layer. Entries without a type flag are
+// regular files.
+type entry struct {
+	name     string
+	content  string
+	typeflag byte
+	linkname string
+}
+
+func dir(name string) entry           { return entry{name: name, typeflag: tar.TypeDir} }
+func file(name, content string) entry { return entry{name: name, content: content} }
+func whiteout(name string) entry      { return entry{name: name} }
+
+// layer builds an uncompressed tar layer.
+func layer(t *testing.T, entries ...entry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Typeflag: e.typeflag, Linkname: e.linkname, Mode: 0o644, Size: int64(len(e.content))}
+		if header.Typeflag == 0 {
+			header.Typeflag = tar.TypeReg
+		}
+		if header.Typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Size > 0 {
+			if _, err := tw.Write([]byte(e.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func contents(files map[string][]byte) map[string]string {
+	got := make(map[string]string, len(files))
+	for name, content := range files {
+		got[name] = string(content)
+	}
+	return got
+}
+
+func TestFilesystemApply(t *testing.T) {
+	base := func(t *testing.T) []byte {
+		return layer(t,
+			dir("bin/"),
+			file("bin/sh", "busybox"),
+			dir("data/"),
+			dir("data/notebook/"),
+			file("data/notebook/nb1.json", "nb1 v1"),
+			file("data/notebook/nb2.json", "nb2 v1"),
+			dir("data/pipeline/"),
+			file("data/pipeline/pl.json", "pl v1"),
+			file("data/publish_config.json", "{}"),
+		)
+	}
+
+	tests := []struct {
+		name   string
+		root   string
+		layers func(t *testing.T) [][]byte
+		want   map[string]string
+	}{
+		{
+			name:   "files below the root only",
+			root:   DefaultRoot,
+			layers: func(t *testing.T) [][]byte { return [][]byte{base(t)} },
+			want: map[string]string{
+				"notebook/nb1.json":   "nb1 v1",
+				"notebook/nb2.json":   "nb2 v1",
+				"pipeline/pl.json":    "pl v1",
+				"publish_config.json": "{}",
+			},
+		},
+		{
+			name: "whole filesystem",
+			root: "/",
+			layers: func(t *testing.T) [][]byte {
+				return [][]byte{layer(t, file("bin/sh", "busybox"), file("./data/x.json", "x"))}
+			},
+			want: map[string]string{"bin/sh": "busybox", "data/x.json": "x"},
+		},
+		{
+			name: "gzip compressed upper layer replaces a file",
+			root: DefaultRoot,
+			layers: func(t *testing.T) [][]byte {
+				return [][]byte{base(t), gzipped(t, layer(t, file("data/notebook/nb1.json", "nb1 v2")))}
+			},
+			want: map[string]string{
+				"notebook/nb1.json":   "nb1 v2",
+				"notebook/nb2.json":   "nb2 v1",
+				"pipeline/pl.json":    "pl v1",
+				"publish_config.json": "{}",
+			},
+		},
+		{
+			name: "whiteout deletes a file",
+			root: DefaultRoot,
+			layers: func(t *testing.T) [][]byte {
+				return [][]byte{base(t), layer(t, whiteout("data/notebook/.wh.nb2.json"))}
+			},
+			want: map[string]string{
+				"notebook/nb1.json":   "nb1 v1",
+				"pipeline/pl.json":    "pl v1",
+				"publish_config.json": "{}",
+			},
+		},
+		{
+			name: "whiteout deletes a directory",
+			root: DefaultRoot,
+			layers: func(t *testing.T) [][]byte {
+				return [][]byte{base(t), layer(t, whiteout("data/.wh.notebook"))}
+			},
+			want: map[string]string{"pipeline/pl.json": "pl v1", "publish_config.json": "{}"},
+		},
+		{
+			name: "opaque whiteout replaces a directory's contents",
+			root: DefaultRoot,
+			layers: func(t *testing.T) [][]byte {
+				return [][]byte{base(t), layer(t,
+					dir("data/notebook/"),
+					whiteout("data/notebook/.wh..wh..opq"),
+					file("data/notebook/nb3.json", "nb3 v1"),
+				)}
+			},
+			want: map[string]string{
+				"notebook/nb3.json":   "nb3 v1",
+				"pipeline/pl.json":    "pl v1",
+				"publish_config.json": "{}",
+			},
+		},
+		{
+			name: "opaque whiteout of the root itself",
+			root: DefaultRoot,
+			layers: func(t *testing.T) [][]byte {
+				return [][]byte{base(t), layer(t, whiteout("data/.wh..wh..opq"), file("data/pipeline/pl.json", "pl v2"))}
+			},
+			want: map[string]string{"pipeline/pl.json": "pl v2"},
+		},
+		{
+			name: "file replaces a directory",
+			root: DefaultRoot,
+			layers: func(t *testing.T) [][]byte {
+				return [][]byte{base(t), layer(t, file("data/notebook", "not a directory"))}
+			},
+			want: map[string]string{
+				"notebook":            "not a directory",
+				"pipeline/pl.json":    "pl v1",
+				"publish_config.json": "{}",
+			},
+		},
+		{
+			name: "hard link",
+			root: DefaultRoot,
+			layers: func(t *testing.T) [][]byte {
+				return [][]byte{layer(t,
+					file("data/notebook/nb1.json", "nb1 v1"),
+					entry{name: "data/notebook/copy.json", typeflag: tar.TypeLink, linkname: "data/notebook/nb1.json"},
+					entry{name: "data/notebook/link.json", typeflag: tar.TypeSymlink, linkname: "nb1.json"},
+				)}
+			},
+			want: map[string]string{"notebook/nb1.json": "nb1 v1", "notebook/copy.json": "nb1 v1"},
+		},
+		{
+			name: "paths cannot escape the root",
+			root: DefaultRoot,
+			layers: func(t *testing.T) [][]byte {
+				return [][]byte{layer(t, file("../../data/a.json", "a"), file("/data/../data/b.json", "b"), file("etc/data/c.json", "c"))}
+			},
+			want: map[string]string{"a.json": "a", "b.json": "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFilesystem(tt.root)
+			for _, l := range tt.layers(t) {
+				if err := fs.Apply(l); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := contents(fs.Files()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilesystemApplyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		layer   []byte
+		wantErr string
+	}{
+		{name: "zstd", layer: []byte{0x28, 0xb5, 0x2f, 0xfd, 0, 0}, wantErr: "zstd compressed layers are not supported"},
+		{name: "broken gzip", layer: []byte{0x1f, 0x8b, 0}, wantErr: "failed to create GZIP reader"},
+		{name: "not a tar", layer: bytes.Repeat([]byte("x"), 1024), wantErr: "failed to read layer entry"},
+	}
+	for _, tt := range tests {
+		err := NewFilesystem(DefaultRoot).Apply(tt.layer)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Apply error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFromDockerArchive(t *testing.T) {
+	manifest := func(layers ...string) []byte {
+		data, err := json.Marshal([]archiveManifest{{Config: "config.json", RepoTags: []string{"artifacts:v1"}, Layers: layers}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	entries := map[string][]byte{
+		"manifest.json":   manifest("base/layer.tar", "upper/layer.tar"),
+		"config.json":     []byte(`{}`),
+		"base/layer.tar":  layer(t, file("bin/sh", "busybox"), file("data/notebook/nb.json", "nb v1"), file("data/pipeline/pl.json", "pl v1")),
+		"upper/layer.tar": layer(t, file("data/notebook/nb.json", "nb v2"), whiteout("data/pipeline/.wh.pl.json")),
+	}
+	if !IsDockerArchive(entries) {
+		t.Fatal("IsDockerArchive = false")
+	}
+	files, err := FromDockerArchive(entries, DefaultRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := contents(files), map[string]string{"notebook/nb.json": "nb v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FromDockerArchive = %v, want %v", got, want)
+	}
+
+	if IsDockerArchive(map[string][]byte{"manifest.json": []byte(`{"formatVersion":1}`)}) {
+		t.Error("IsDockerArchive accepted a package manifest")
+	}
+
+	entries["manifest.json"] = manifest("base/layer.tar", "missing/layer.tar")
+	if _, err := FromDockerArchive(entries, DefaultRoot); err == nil || !strings.Contains(err.Error(), "lacks layer missing/layer.tar") {
+		t.Errorf("FromDockerArchive with a missing layer error = %v", err)
+	}
+
+	two, err := json.Marshal([]archiveManifest{{Layers: []string{"base/layer.tar"}}, {Layers: []string{"upper/layer.tar"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries["manifest.json"] = two
+	if _, err := FromDockerArchive(entries, DefaultRoot); err == nil || !strings.Contains(err.Error(), "holds 2 images, want 1") {
+		t.Errorf("FromDockerArchive of two images error = %v", err)
+	}
+}
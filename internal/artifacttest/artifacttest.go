@@ -0,0 +1,122 @@
+// Package artifacttest provides artifacts and an in-memory workspace for the
+// tests of the packages that order, compare, deploy and prune artifacts.
+package artifacttest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/target"
+)
+
+// New builds the artifact a package file at path holds, failing the test
+// when it cannot.
+func New(t testing.TB, path, content string) artifact.Artifact {
+	t.Helper()
+	a, err := artifact.New(path, []byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// Def returns an artifact of the given type and name whose activities
+// reference each of refs, written ReferenceType/name, such as
+// NotebookReference/nb1.
+func Def(t testing.TB, artifactType, name string, refs ...string) artifact.Artifact {
+	t.Helper()
+	var objects []string
+	for _, ref := range refs {
+		refType, refName, _ := strings.Cut(ref, "/")
+		objects = append(objects, fmt.Sprintf(`{"referenceName":%q,"type":%q}`, refName, refType))
+	}
+	return New(t, artifactType+"/"+name+".json",
+		fmt.Sprintf(`{"name":%q,"properties":{"activities":[%s]}}`, name, strings.Join(objects, ",")))
+}
+
+// Keys returns the keys of artifacts, in order.
+func Keys(artifacts []artifact.Artifact) []string {
+	var keys []string
+	for _, a := range artifacts {
+		keys = append(keys, a.Key())
+	}
+	return keys
+}
+
+// Workspace is a target.Reader and target.Deleter holding artifacts in
+// memory. It is safe for concurrent use.
+type Workspace struct {
+	// Errs makes listing a type fail with its error.
+	Errs map[string]error
+
+	mu        sync.Mutex
+	artifacts map[string][]artifact.Artifact
+	listed    []string
+	deleted   []string
+}
+
+// NewWorkspace returns a workspace holding artifacts.
+func NewWorkspace(artifacts ...artifact.Artifact) *Workspace {
+	w := &Workspace{Errs: make(map[string]error), artifacts: make(map[string][]artifact.Artifact)}
+	for _, a := range artifacts {
+		w.artifacts[a.Type] = append(w.artifacts[a.Type], a)
+	}
+	return w
+}
+
+// Get implements target.Reader.
+func (w *Workspace) Get(ctx context.Context, artifactType, name string) ([]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, a := range w.artifacts[artifactType] {
+		if a.Name == name {
+			return a.Content, nil
+		}
+	}
+	return nil, target.ErrNotFound
+}
+
+// List implements target.Reader.
+func (w *Workspace) List(ctx context.Context, artifactType string) ([]artifact.Artifact, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.listed = append(w.listed, artifactType)
+	if err := w.Errs[artifactType]; err != nil {
+		return nil, err
+	}
+	return append([]artifact.Artifact(nil), w.artifacts[artifactType]...), nil
+}
+
+// Delete implements target.Deleter, failing with target.ErrNotFound for an
+// artifact the workspace does not hold.
+func (w *Workspace) Delete(ctx context.Context, artifactType, name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	held := w.artifacts[artifactType]
+	for i, a := range held {
+		if a.Name == name {
+			w.artifacts[artifactType] = append(held[:i:i], held[i+1:]...)
+			w.deleted = append(w.deleted, a.Key())
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s/%s", target.ErrNotFound, artifactType, name)
+}
+
+// Listed returns the types List was called with, in order.
+func (w *Workspace) Listed() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.listed...)
+}
+
+// Deleted returns the keys of the artifacts deleted, in order.
+func (w *Workspace) Deleted() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.deleted...)
+}
@@ -0,0 +1,49 @@
+package manifest
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// SourceFromEnv reads the repository and commit of a CI job: GitLab CI,
+// Azure Pipelines or GitHub Actions. Fields are empty outside CI.
+func SourceFromEnv(getenv func(string) string) Source {
+	switch {
+	case getenv("CI_COMMIT_SHA") != "":
+		return Source{Repository: getenv("CI_PROJECT_URL"), Commit: getenv("CI_COMMIT_SHA")}
+	case getenv("BUILD_SOURCEVERSION") != "":
+		return Source{Repository: getenv("BUILD_REPOSITORY_URI"), Commit: getenv("BUILD_SOURCEVERSION")}
+	case getenv("GITHUB_SHA") != "":
+		return Source{Repository: getenv("GITHUB_SERVER_URL") + "/" + getenv("GITHUB_REPOSITORY"), Commit: getenv("GITHUB_SHA")}
+	default:
+		return Source{}
+	}
+}
+
+// SourceFromGit reads the origin URL and HEAD commit of the Git checkout
+// holding dir. Fields are empty when git or the checkout is unavailable.
+func SourceFromGit(dir string) Source {
+	return Source{
+		Repository: git(dir, "remote", "get-url", "origin"),
+		Commit:     git(dir, "rev-parse", "HEAD"),
+	}
+}
+
+func git(dir string, args ...string) string {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Or returns s with its empty fields taken from other.
+func (s Source) Or(other Source) Source {
+	if s.Repository == "" {
+		s.Repository = other.Repository
+	}
+	if s.Commit == "" {
+		s.Commit = other.Commit
+	}
+	return s
+}
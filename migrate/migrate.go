@@ -0,0 +1,44 @@
+// Package migrate prepares the artifacts of one workspace for another.
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+)
+
+// Retarget drops the workspace default linked services of the source
+// workspace, whatever its name, and points the references to them at the
+// defaults of the named target workspace, which Synapse created with it.
+func Retarget(artifacts []artifact.Artifact, workspace string) []artifact.Artifact {
+	renames := make(map[string]string)
+	var kept []artifact.Artifact
+	for _, a := range artifacts {
+		if name, ok := artifact.WorkspaceDefaultIn(a, workspace); ok {
+			renames[a.Name] = name
+			fmt.Printf("Skipped workspace default linked service: %s\n", a.Name)
+			continue
+		}
+		kept = append(kept, a)
+	}
+
+	for i := range kept {
+		for from, to := range renames {
+			kept[i].Content = replaceString(kept[i].Content, from, to)
+		}
+	}
+	return kept
+}
+
+// replaceString replaces the JSON strings equal to from, such as a
+// referenceName, leaving strings that merely contain it alone.
+func replaceString(content []byte, from, to string) []byte {
+	if from == to {
+		return content
+	}
+	quotedFrom, _ := json.Marshal(from)
+	quotedTo, _ := json.Marshal(to)
+	return bytes.ReplaceAll(content, quotedFrom, quotedTo)
+}
@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+)
+
+func TestRetarget(t *testing.T) {
+	var artifacts []artifact.Artifact
+	for _, f := range []struct{ path, def string }{
+		{"linkedService/syn-ws-dev-WorkspaceDefaultStorage.json", `{"name":"syn-ws-dev-WorkspaceDefaultStorage"}`},
+		{"linkedService/syn-ws-dev-WorkspaceDefaultSqlServer.json", `{"name":"syn-ws-dev-WorkspaceDefaultSqlServer"}`},
+		{"linkedService/syn-ws-dev-WorkspaceDefaultKeyVault.json", `{"name":"syn-ws-dev-WorkspaceDefaultKeyVault"}`},
+		{"linkedService/WorkspaceDefaultStorage.json", `{"name":"WorkspaceDefaultStorage"}`},
+		{"dataset/ds_raw.json", `{"name":"ds_raw","properties":{"linkedServiceName":{"referenceName":"syn-ws-dev-WorkspaceDefaultStorage","type":"LinkedServiceReference"},"description":"copy of syn-ws-dev-WorkspaceDefaultStorage"}}`},
+		{"pipeline/pl.json", `{"name":"pl","properties":{"activities":[{"linkedServiceName":{"referenceName":"syn-ws-dev-WorkspaceDefaultSqlServer","type":"LinkedServiceReference"}}]}}`},
+	} {
+		a, err := artifact.New(f.path, []byte(f.def))
+		if err != nil {
+			t.Fatal(err)
+		}
+		artifacts = append(artifacts, a)
+	}
+
+	got := make(map[string]string)
+	for _, a := range Retarget(artifacts, "syn-ws-prod") {
+		got[a.Key()] = string(a.Content)
+	}
+
+	want := map[string]string{
+		// Without a workspace name in front it is an ordinary linked service
+		"linkedService/WorkspaceDefaultStorage": `{"name":"WorkspaceDefaultStorage"}`,
+		// A reference is renamed, a string that merely mentions it is not
+		"dataset/ds_raw": `{"name":"ds_raw","properties":{"linkedServiceName":{"referenceName":"syn-ws-prod-WorkspaceDefaultStorage","type":"LinkedServiceReference"},"description":"copy of syn-ws-dev-WorkspaceDefaultStorage"}}`,
+		"pipeline/pl":    `{"name":"pl","properties":{"activities":[{"linkedServiceName":{"referenceName":"syn-ws-prod-WorkspaceDefaultSqlServer","type":"LinkedServiceReference"}}]}}`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Retarget =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestWorkspaceDefaultIn(t *testing.T) {
+	tests := []struct {
+		artifactType, name string
+		want               string
+		wantOK             bool
+	}{
+		{artifact.LinkedService, "ws1-WorkspaceDefaultStorage", "ws2-WorkspaceDefaultStorage", true},
+		{artifact.LinkedService, "ws1-WorkspaceDefaultSqlServer", "ws2-WorkspaceDefaultSqlServer", true},
+		{artifact.LinkedService, "my-ws-1-WorkspaceDefaultAnything", "ws2-WorkspaceDefaultAnything", true},
+		{artifact.LinkedService, "WorkspaceDefaultStorage", "", false},
+		{artifact.LinkedService, "-WorkspaceDefaultStorage", "", false},
+		{artifact.LinkedService, "ls_storage", "", false},
+		{artifact.Notebook, "ws1-WorkspaceDefaultStorage", "", false},
+	}
+
+	for _, tt := range tests {
+		a := artifact.Artifact{Type: tt.artifactType, Name: tt.name}
+		got, ok := artifact.WorkspaceDefaultIn(a, "ws2")
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("WorkspaceDefaultIn(%s/%s) = %q, %v, want %q, %v", tt.artifactType, tt.name, got, ok, tt.want, tt.wantOK)
+		}
+		if artifact.IsWorkspaceDefault(a) != tt.wantOK {
+			t.Errorf("IsWorkspaceDefault(%s/%s) = %v, want %v", tt.artifactType, tt.name, !tt.wantOK, tt.wantOK)
+		}
+	}
+}
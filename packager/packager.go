@@ -0,0 +1,101 @@
+// Package packager builds artifact packages: zip files holding artifact
+// definitions in the Synapse Git layout next to a manifest.
+package packager
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/archive"
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+)
+
+// Collect reads the artifacts below root. JSON files that do not fit the
+// layout, such as publish_config.json or an unknown type folder, are left
+// out and returned as skipped; other files and hidden directories are
+// ignored.
+func Collect(root string) (artifacts []artifact.Artifact, skipped []string, err error) {
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !artifact.IsJSON(p) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %v", p, err)
+		}
+		rel = filepath.ToSlash(rel)
+		if !artifact.InLayout(rel) {
+			skipped = append(skipped, rel)
+			return nil
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %v", p, err)
+		}
+		a, err := artifact.New(rel, content)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, a)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to collect artifacts in %s: %v", root, err)
+	}
+
+	artifact.Sort(artifacts)
+	return artifacts, skipped, nil
+}
+
+// Build zips artifacts at their layout paths together with a manifest
+// recording src.
+func Build(artifacts []artifact.Artifact, src manifest.Source) ([]byte, error) {
+	files := artifact.ToFiles(artifacts)
+	if len(files) != len(artifacts) {
+		return nil, fmt.Errorf("package holds artifacts with the same type and name")
+	}
+
+	sorted := append([]artifact.Artifact(nil), artifacts...)
+	artifact.Sort(sorted)
+	data, err := manifest.New(sorted, src).Marshal()
+	if err != nil {
+		return nil, err
+	}
+	files[manifest.FileName] = data
+
+	return archive.Zip(files)
+}
+
+// Directory packages the artifacts below root, printing the files it
+// leaves out. Empty fields of src are filled from the CI job, then from the
+// Git checkout holding root.
+func Directory(root string, src manifest.Source) ([]byte, error) {
+	artifacts, skipped, err := Collect(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range skipped {
+		fmt.Printf("Skipped %s: %s\n", rel, artifact.SkipReason(rel))
+	}
+	if len(artifacts) == 0 {
+		return nil, fmt.Errorf("no artifacts found in %s", root)
+	}
+
+	src = src.Or(manifest.SourceFromEnv(os.Getenv)).Or(manifest.SourceFromGit(root))
+	return Build(artifacts, src)
+}
@@ -0,0 +1,143 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/secret"
+)
+
+// volatileFields are top-level fields the workspace sets on its own, so they
+// never reflect a change in the package. The resource type is implied by the
+// collection an artifact lives in, and Git exports often leave it out.
+var volatileFields = []string{"id", "etag", "type"}
+
+// Normalize parses an artifact definition and drops the fields Synapse
+// maintains itself (id, etag, type and properties.lastPublishTime), so a
+// package file and the workspace copy of the same artifact compare equal.
+// Object key order is irrelevant once parsed.
+func Normalize(content []byte) (interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse definition: %v", err)
+	}
+
+	if obj, ok := doc.(map[string]interface{}); ok {
+		for _, field := range volatileFields {
+			delete(obj, field)
+		}
+		if props, ok := obj["properties"].(map[string]interface{}); ok {
+			delete(props, "lastPublishTime")
+		}
+	}
+	return doc, nil
+}
+
+// Diff lists the differences between two normalized definitions, one line
+// per changed value:
+//
+//	~ properties.description: "old" => "new"
+//	+ properties.annotations[0]: "added"
+//	- properties.folder: {"name":"removed"}
+//
+// Values are masked with redactor, which may be nil, before long ones are
+// shortened, so a secret cut in half is not printed in part.
+func Diff(old, new interface{}, redactor *secret.Redactor) []string {
+	var lines []string
+	d := differ{redactor: redactor}
+	d.diff("", old, new, &lines)
+	return lines
+}
+
+type differ struct {
+	redactor *secret.Redactor
+}
+
+func (d differ) diff(path string, old, new interface{}, lines *[]string) {
+	switch o := old.(type) {
+	case map[string]interface{}:
+		if n, ok := new.(map[string]interface{}); ok {
+			for _, key := range unionKeys(o, n) {
+				ov, inOld := o[key]
+				nv, inNew := n[key]
+				childPath := joinKey(path, key)
+				switch {
+				case !inOld:
+					*lines = append(*lines, fmt.Sprintf("+ %s: %s", childPath, d.render(nv)))
+				case !inNew:
+					*lines = append(*lines, fmt.Sprintf("- %s: %s", childPath, d.render(ov)))
+				default:
+					d.diff(childPath, ov, nv, lines)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if n, ok := new.([]interface{}); ok {
+			for i := 0; i < len(o) || i < len(n); i++ {
+				childPath := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(o):
+					*lines = append(*lines, fmt.Sprintf("+ %s: %s", childPath, d.render(n[i])))
+				case i >= len(n):
+					*lines = append(*lines, fmt.Sprintf("- %s: %s", childPath, d.render(o[i])))
+				default:
+					d.diff(childPath, o[i], n[i], lines)
+				}
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		if path == "" {
+			path = "."
+		}
+		*lines = append(*lines, fmt.Sprintf("~ %s: %s => %s", path, d.render(old), d.render(new)))
+	}
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var plainKey = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func joinKey(path, key string) string {
+	if !plainKey.MatchString(key) {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// maxRendered caps how much of a value is printed in a diff line.
+const maxRendered = 120
+
+func (d differ) render(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	s := d.redactor.Redact(string(b))
+	if len(s) > maxRendered {
+		s = strings.ToValidUTF8(s[:maxRendered], "") + "..."
+	}
+	return s
+}
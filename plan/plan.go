@@ -0,0 +1,142 @@
+// Package plan compares an artifact package with what a deploy target
+// already holds and reports what a deploy would change, without writing
+// anything.
+package plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/prune"
+	"github.com/utsavudhungana/artifactsrepo/secret"
+	"github.com/utsavudhungana/artifactsrepo/target"
+)
+
+// Action is what a deploy would do to an artifact.
+type Action string
+
+// Plan actions.
+const (
+	Create    Action = "create"
+	Update    Action = "update"
+	Unchanged Action = "unchanged"
+	Delete    Action = "delete"
+)
+
+// symbols prefix each action in the printed plan.
+var symbols = map[Action]string{
+	Create:    "+",
+	Update:    "~",
+	Unchanged: "=",
+	Delete:    "-",
+}
+
+// Change is the planned action for one artifact.
+type Change struct {
+	Action Action
+	Type   string
+	Name   string
+	// Diff lists the changed values of an update, as returned by Diff.
+	Diff []string
+}
+
+// Key identifies the artifact as type/name.
+func (c Change) Key() string {
+	return c.Type + "/" + c.Name
+}
+
+// Plan lists the changes of a deploy in deploy order, followed by the
+// artifacts a prune would delete in deletion order.
+type Plan struct {
+	Changes []Change
+}
+
+// Build fetches every package artifact from the target and compares it with
+// the package definition. Read-only artifacts and workspace default linked
+// services, which a deploy skips, are left out. Values in the diff of an
+// update are masked with redactor, which may be nil.
+func Build(ctx context.Context, artifacts []artifact.Artifact, reader target.Reader, redactor *secret.Redactor) (*Plan, error) {
+	p := &Plan{}
+
+	for _, a := range artifacts {
+		if artifact.IsReadOnly(a.Type) || artifact.IsWorkspaceDefault(a) {
+			continue
+		}
+		current, err := reader.Get(ctx, a.Type, a.Name)
+		if errors.Is(err, target.ErrNotFound) {
+			p.Changes = append(p.Changes, Change{Action: Create, Type: a.Type, Name: a.Name})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s: %v", a.Key(), err)
+		}
+
+		change, err := compare(a, current, redactor)
+		if err != nil {
+			return nil, err
+		}
+		p.Changes = append(p.Changes, change)
+	}
+
+	return p, nil
+}
+
+// Prune lists the artifacts a prune would delete: those on the target that
+// are missing from the package and not protected by filter. Only a pruning
+// deploy needs it, since it lists every artifact type on the target.
+func (p *Plan) Prune(ctx context.Context, artifacts []artifact.Artifact, reader target.Reader, filter prune.Filter) error {
+	orphans, err := prune.Orphans(ctx, artifacts, reader, filter)
+	if err != nil {
+		return err
+	}
+	for _, o := range orphans {
+		p.Changes = append(p.Changes, Change{Action: Delete, Type: o.Type, Name: o.Name})
+	}
+	return nil
+}
+
+func compare(a artifact.Artifact, current []byte, redactor *secret.Redactor) (Change, error) {
+	want, err := Normalize(a.Content)
+	if err != nil {
+		return Change{}, fmt.Errorf("%s in package: %v", a.Key(), err)
+	}
+	have, err := Normalize(current)
+	if err != nil {
+		return Change{}, fmt.Errorf("%s in target: %v", a.Key(), err)
+	}
+
+	change := Change{Action: Unchanged, Type: a.Type, Name: a.Name}
+	if lines := Diff(have, want, redactor); len(lines) > 0 {
+		change.Action = Update
+		change.Diff = lines
+	}
+	return change, nil
+}
+
+// Count returns how many changes have the given action.
+func (p *Plan) Count(action Action) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Write prints the plan, one line per artifact with the diff of each update
+// indented below it, followed by a summary line.
+func (p *Plan) Write(w io.Writer) {
+	for _, c := range p.Changes {
+		fmt.Fprintf(w, "%s %-9s %s\n", symbols[c.Action], c.Action, c.Key())
+		for _, line := range c.Diff {
+			fmt.Fprintf(w, "      %s\n", line)
+		}
+	}
+
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d unchanged, %d to delete.\n",
+		p.Count(Create), p.Count(Update), p.Count(Unchanged), p.Count(Delete))
+}
@@ -0,0 +1,123 @@
+package plan
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/internal/artifacttest"
+	"github.com/utsavudhungana/artifactsrepo/secret"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{
+			name: "equal after normalizing",
+			old:  `{"id":"/x","etag":"1","name":"nb","properties":{"lastPublishTime":"t","a":1}}`,
+			new:  `{"properties":{"a":1},"name":"nb"}`,
+		},
+		{
+			name: "changed, added and removed values",
+			old:  `{"properties":{"description":"old","folder":{"name":"f"},"tags":["a"]}}`,
+			new:  `{"properties":{"description":"new","tags":["a","b"],"conf":{"spark.x":"1"}}}`,
+			want: []string{
+				`+ properties.conf: {"spark.x":"1"}`,
+				`~ properties.description: "old" => "new"`,
+				`- properties.folder: {"name":"f"}`,
+				`+ properties.tags[1]: "b"`,
+			},
+		},
+		{
+			name: "long values differing after the cut",
+			old:  `{"v":"` + strings.Repeat("a", 200) + `1"}`,
+			new:  `{"v":"` + strings.Repeat("a", 200) + `2"}`,
+			want: []string{`~ v: "` + strings.Repeat("a", maxRendered-1) + `... => "` + strings.Repeat("a", maxRendered-1) + `...`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, err := Normalize([]byte(tt.old))
+			if err != nil {
+				t.Fatal(err)
+			}
+			new, err := Normalize([]byte(tt.new))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Diff(old, new, nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestBuildSkipsWorkspaceOwned(t *testing.T) {
+	artifacts := []artifact.Artifact{
+		artifacttest.New(t, "integrationRuntime/AutoResolveIntegrationRuntime.json", `{"name":"AutoResolveIntegrationRuntime"}`),
+		artifacttest.New(t, "linkedService/synws-dev-WorkspaceDefaultSqlServer.json", `{"name":"synws-dev-WorkspaceDefaultSqlServer"}`),
+		artifacttest.New(t, "linkedService/synws-dev-WorkspaceDefaultStorage.json", `{"name":"synws-dev-WorkspaceDefaultStorage"}`),
+		artifacttest.New(t, "linkedService/ls_lake.json", `{"name":"ls_lake","properties":{"a":2}}`),
+		artifacttest.New(t, "notebook/nb.json", `{"name":"nb","properties":{"a":1}}`),
+	}
+	artifact.Sort(artifacts)
+	ws := artifacttest.NewWorkspace(
+		artifacttest.New(t, "linkedService/ls_lake.json", `{"name":"ls_lake","properties":{"a":1}}`),
+		artifacttest.New(t, "notebook/nb.json", `{"name":"nb","properties":{"a":1}}`),
+	)
+
+	p, err := Build(context.Background(), artifacts, ws, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range p.Changes {
+		got = append(got, string(c.Action)+" "+c.Key())
+	}
+	if want := []string{"update linkedService/ls_lake", "unchanged notebook/nb"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("plan = %v, want %v", got, want)
+	}
+}
+
+func TestWriteRedactsLongValues(t *testing.T) {
+	const password = "Kv-S3cret-Passw0rd!"
+	redactor := &secret.Redactor{}
+	redactor.Add(password)
+
+	// The password straddles the point where a printed value is cut
+	connection := "Server=tcp:sql-prod.database.windows.net,1433;Initial Catalog=warehouse;User ID=deployer;" +
+		"App=deploy;Password=" + password + ";Encrypt=True;"
+	// Rendered as a JSON string, the value starts with a quote
+	if i := strings.Index(connection, password) + 1; i >= maxRendered || i+len(password) <= maxRendered {
+		t.Fatalf("password at %d does not cross the cut at %d", i, maxRendered)
+	}
+
+	a := artifacttest.New(t, "linkedService/ls_sql.json",
+		`{"name":"ls_sql","properties":{"typeProperties":{"connectionString":"`+connection+`"}}}`)
+	ws := artifacttest.NewWorkspace(artifacttest.New(t, "linkedService/ls_sql.json",
+		`{"name":"ls_sql","properties":{"typeProperties":{"connectionString":"Server=tcp:sql-dev"}}}`))
+
+	p, err := Build(context.Background(), []artifact.Artifact{a}, ws, redactor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	p.Write(&out)
+
+	if p.Count(Update) != 1 {
+		t.Fatalf("plan has %d updates, want 1:\n%s", p.Count(Update), out.String())
+	}
+	if strings.Contains(out.String(), password[:4]) {
+		t.Errorf("plan shows part of the password:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Password="+secret.Mask) {
+		t.Errorf("plan does not mask the password:\n%s", out.String())
+	}
+}
@@ -0,0 +1,110 @@
+// Package prune finds and deletes the artifacts a target holds that are no
+// longer part of the package, so renamed and removed artifacts do not pile
+// up in workspaces.
+package prune
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/graph"
+	"github.com/utsavudhungana/artifactsrepo/target"
+)
+
+// Filter decides which artifacts prune must leave alone.
+type Filter struct {
+	// Exclude holds path.Match patterns. A pattern containing a slash is
+	// matched against type/name, such as notebook/Scratch*; any other
+	// pattern is matched against the name alone.
+	Exclude []string
+}
+
+// Validate reports malformed exclusion patterns.
+func (f Filter) Validate() error {
+	for _, pattern := range f.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclusion pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Protected reports whether a must not be deleted: workspace default linked
+// services always are, other artifacts when an exclusion pattern matches.
+func (f Filter) Protected(a artifact.Artifact) bool {
+	if artifact.IsWorkspaceDefault(a) {
+		return true
+	}
+
+	for _, pattern := range f.Exclude {
+		subject := a.Name
+		if strings.Contains(pattern, "/") {
+			subject = a.Key()
+		}
+		if ok, _ := path.Match(pattern, subject); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Orphans lists every artifact type on the target and returns the artifacts
+// that are neither in the package nor protected, in the order they can be
+// deleted: artifacts that reference others come before what they reference.
+// Read-only types, such as the built-in AutoResolveIntegrationRuntime, are
+// never pruned, and types the target does not have hold no orphans.
+func Orphans(ctx context.Context, artifacts []artifact.Artifact, reader target.Reader, filter Filter) ([]artifact.Artifact, error) {
+	inPackage := make(map[string]bool, len(artifacts))
+	for _, a := range artifacts {
+		inPackage[a.Key()] = true
+	}
+
+	var orphans []artifact.Artifact
+	for _, artifactType := range artifact.Types {
+		if artifact.IsReadOnly(artifactType) {
+			continue
+		}
+		existing, err := reader.List(ctx, artifactType)
+		if errors.Is(err, target.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s artifacts: %v", artifactType, err)
+		}
+
+		for _, e := range existing {
+			if !inPackage[e.Key()] && !filter.Protected(e) {
+				orphans = append(orphans, e)
+			}
+		}
+	}
+
+	g, err := graph.Build(orphans)
+	if err != nil {
+		return nil, err
+	}
+	sorted, err := g.Sort()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(sorted)-1; i < j; i, j = i+1, j-1 {
+		sorted[i], sorted[j] = sorted[j], sorted[i]
+	}
+	return sorted, nil
+}
+
+// Delete removes the orphans from the target in the order given.
+func Delete(ctx context.Context, deleter target.Deleter, orphans []artifact.Artifact) error {
+	for _, a := range orphans {
+		if err := deleter.Delete(ctx, a.Type, a.Name); err != nil {
+			return fmt.Errorf("failed to delete artifact %s: %v", a.Key(), err)
+		}
+		fmt.Printf("Deleted artifact: %s (type: %s)\n", a.Name, a.Type)
+	}
+	return nil
+}
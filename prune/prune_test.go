@@ -0,0 +1,146 @@
+package prune
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/internal/artifacttest"
+	"github.com/utsavudhungana/artifactsrepo/target"
+)
+
+func TestProtected(t *testing.T) {
+	filter := Filter{Exclude: []string{"Scratch*", "notebook/Tmp*"}}
+	tests := []struct {
+		a    artifact.Artifact
+		want bool
+	}{
+		{artifacttest.Def(t, artifact.LinkedService, "synws-dev-WorkspaceDefaultStorage"), true},
+		{artifacttest.Def(t, artifact.LinkedService, "synws-dev-WorkspaceDefaultSqlServer"), true},
+		{artifacttest.Def(t, artifact.LinkedService, "ls_lake"), false},
+		{artifacttest.Def(t, artifact.Notebook, "ScratchPad"), true},
+		{artifacttest.Def(t, artifact.Pipeline, "Scratch"), true},
+		{artifacttest.Def(t, artifact.Notebook, "MyScratch"), false},
+		{artifacttest.Def(t, artifact.Notebook, "TmpRun"), true},
+		{artifacttest.Def(t, artifact.SQLScript, "TmpRun"), false},
+	}
+	for _, tt := range tests {
+		if got := filter.Protected(tt.a); got != tt.want {
+			t.Errorf("Protected(%s) = %v, want %v", tt.a.Key(), got, tt.want)
+		}
+	}
+
+	if (Filter{}).Protected(artifacttest.Def(t, artifact.Notebook, "Scratch")) {
+		t.Error("an empty filter protects notebook/Scratch")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Filter{Exclude: []string{"Scratch*", "notebook/Tmp?"}}).Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	if err := (Filter{Exclude: []string{"Scratch["}}).Validate(); err == nil {
+		t.Error("Validate accepted an unterminated character class")
+	}
+}
+
+func TestOrphans(t *testing.T) {
+	packaged := []artifact.Artifact{
+		artifacttest.Def(t, artifact.Notebook, "nb_keep"),
+		artifacttest.Def(t, artifact.Pipeline, "pl_keep", "NotebookReference/nb_keep"),
+	}
+	w := artifacttest.NewWorkspace(
+		artifacttest.Def(t, artifact.LinkedService, "synws-dev-WorkspaceDefaultStorage"),
+		artifacttest.Def(t, artifact.LinkedService, "ls_old"),
+		artifacttest.Def(t, artifact.Dataset, "ds_old", "LinkedServiceReference/ls_old"),
+		artifacttest.Def(t, artifact.Notebook, "nb_keep"),
+		artifacttest.Def(t, artifact.Notebook, "nb_old"),
+		artifacttest.Def(t, artifact.Notebook, "ScratchPad"),
+		artifacttest.Def(t, artifact.Pipeline, "pl_keep", "NotebookReference/nb_keep"),
+		artifacttest.Def(t, artifact.Pipeline, "pl_old", "NotebookReference/nb_old", "DatasetReference/ds_old"),
+		artifacttest.Def(t, artifact.Trigger, "tr_old", "PipelineReference/pl_old"),
+	)
+	w.Errs[artifact.KQLScript] = target.ErrNotFound
+	w.Errs[artifact.SparkConfiguration] = fmt.Errorf("%w: GET /sparkconfigurations returned 404", target.ErrNotFound)
+
+	orphans, err := Orphans(context.Background(), packaged, w, Filter{Exclude: []string{"Scratch*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := artifacttest.Keys(orphans)
+	position := make(map[string]int)
+	for i, key := range got {
+		position[key] = i
+	}
+	want := []string{"linkedService/ls_old", "dataset/ds_old", "notebook/nb_old", "pipeline/pl_old", "trigger/tr_old"}
+	if len(got) != len(want) {
+		t.Fatalf("Orphans = %v, want %v in some order", got, want)
+	}
+	for _, key := range want {
+		if _, ok := position[key]; !ok {
+			t.Errorf("Orphans = %v, missing %s", got, key)
+		}
+	}
+	// what references an artifact is deleted before it
+	for _, edge := range [][2]string{
+		{"trigger/tr_old", "pipeline/pl_old"},
+		{"pipeline/pl_old", "notebook/nb_old"},
+		{"pipeline/pl_old", "dataset/ds_old"},
+		{"dataset/ds_old", "linkedService/ls_old"},
+	} {
+		if position[edge[0]] > position[edge[1]] {
+			t.Errorf("Orphans = %v: %s before %s", got, edge[1], edge[0])
+		}
+	}
+
+	if err := Delete(context.Background(), w, orphans); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(w.Deleted(), got) {
+		t.Errorf("deleted %v, want %v", w.Deleted(), got)
+	}
+	for _, artifactType := range w.Listed() {
+		if artifact.IsReadOnly(artifactType) {
+			t.Errorf("listed read-only type %s", artifactType)
+		}
+	}
+}
+
+func TestOrphansListFailure(t *testing.T) {
+	w := artifacttest.NewWorkspace()
+	w.Errs[artifact.Notebook] = errors.New("GET /notebooks returned 403")
+	_, err := Orphans(context.Background(), nil, w, Filter{})
+	if err == nil || !strings.Contains(err.Error(), "failed to list notebook artifacts") {
+		t.Fatalf("Orphans error = %v, want the list failure", err)
+	}
+}
+
+func TestDeleteStopsOnFailure(t *testing.T) {
+	var deleted []string
+	d := deleterFunc(func(artifactType, name string) error {
+		if name == "b" {
+			return errors.New("denied")
+		}
+		deleted = append(deleted, name)
+		return nil
+	})
+	orphans := []artifact.Artifact{artifacttest.Def(t, artifact.Notebook, "a"), artifacttest.Def(t, artifact.Notebook, "b"), artifacttest.Def(t, artifact.Notebook, "c")}
+	err := Delete(context.Background(), d, orphans)
+	if err == nil || !strings.Contains(err.Error(), "failed to delete artifact notebook/b: denied") {
+		t.Fatalf("Delete error = %v", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"a"}) {
+		t.Errorf("deleted %v, want only what came before the failure", deleted)
+	}
+}
+
+type deleterFunc func(artifactType, name string) error
+
+func (f deleterFunc) Delete(ctx context.Context, artifactType, name string) error {
+	return f(artifactType, name)
+}
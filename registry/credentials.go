@@ -0,0 +1,228 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+
+	"github.com/utsavudhungana/artifactsrepo/httpclient"
+	"github.com/utsavudhungana/artifactsrepo/synapse"
+)
+
+// CredentialProvider supplies the credential for a registry host, such as
+// myacr.azurecr.io. An empty credential means anonymous access. Push, pull
+// and every other registry operation authenticate through one.
+type CredentialProvider interface {
+	Credential(ctx context.Context, registry string) (auth.Credential, error)
+}
+
+// Auth modes select the credential provider of ProviderFor.
+const (
+	// AuthAuto uses REGISTRY_USERNAME/REGISTRY_PASSWORD when set, then the
+	// Docker config, then anonymous access.
+	AuthAuto      = ""
+	AuthEnv       = "env"
+	AuthDocker    = "docker"
+	AuthACR       = "acr"
+	AuthAnonymous = "anonymous"
+)
+
+// ProviderFor returns the credential provider of an auth mode. azure is the
+// identity exchanged for a registry token in AuthACR mode.
+func ProviderFor(mode string, azure synapse.Credentials) (CredentialProvider, error) {
+	switch mode {
+	case AuthAuto:
+		return Chain{CredentialsFromEnv(), &DockerConfig{}}, nil
+	case AuthEnv:
+		cred := CredentialsFromEnv()
+		if cred.Username == "" && cred.Password == "" {
+			return nil, fmt.Errorf("%s registry auth needs REGISTRY_USERNAME and REGISTRY_PASSWORD", AuthEnv)
+		}
+		return cred, nil
+	case AuthDocker:
+		return &DockerConfig{}, nil
+	case AuthACR:
+		return &ACR{Credentials: azure}, nil
+	case AuthAnonymous:
+		return Anonymous{}, nil
+	default:
+		return nil, fmt.Errorf("unknown registry auth mode %q: use %s, %s, %s or %s",
+			mode, AuthEnv, AuthDocker, AuthACR, AuthAnonymous)
+	}
+}
+
+// Credentials holds static registry credentials. Empty credentials mean
+// anonymous access.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// CredentialsFromEnv reads REGISTRY_USERNAME and REGISTRY_PASSWORD.
+func CredentialsFromEnv() Credentials {
+	return Credentials{
+		Username: os.Getenv("REGISTRY_USERNAME"),
+		Password: os.Getenv("REGISTRY_PASSWORD"),
+	}
+}
+
+// Credential implements CredentialProvider.
+func (c Credentials) Credential(ctx context.Context, registry string) (auth.Credential, error) {
+	return auth.Credential{Username: c.Username, Password: c.Password}, nil
+}
+
+// Anonymous accesses registries without credentials.
+type Anonymous struct{}
+
+// Credential implements CredentialProvider.
+func (Anonymous) Credential(ctx context.Context, registry string) (auth.Credential, error) {
+	return auth.EmptyCredential, nil
+}
+
+// Chain asks each provider in turn and returns the first non-empty
+// credential.
+type Chain []CredentialProvider
+
+// Credential implements CredentialProvider.
+func (c Chain) Credential(ctx context.Context, registry string) (auth.Credential, error) {
+	for _, p := range c {
+		cred, err := p.Credential(ctx, registry)
+		if err != nil {
+			return auth.EmptyCredential, err
+		}
+		if cred != auth.EmptyCredential {
+			return cred, nil
+		}
+	}
+	return auth.EmptyCredential, nil
+}
+
+// DockerConfig reads credentials the way docker login stores them: from
+// the config.json in DOCKER_CONFIG or ~/.docker, including credsStore and
+// credHelpers credential helpers. A missing config means no credentials.
+type DockerConfig struct {
+	// Path overrides the location of config.json.
+	Path string
+
+	once  sync.Once
+	store credentials.Store
+	err   error
+}
+
+// Credential implements CredentialProvider.
+func (d *DockerConfig) Credential(ctx context.Context, registry string) (auth.Credential, error) {
+	d.once.Do(func() {
+		if d.Path != "" {
+			d.store, d.err = credentials.NewStore(d.Path, credentials.StoreOptions{})
+		} else {
+			d.store, d.err = credentials.NewStoreFromDocker(credentials.StoreOptions{})
+		}
+	})
+	if d.err != nil {
+		return auth.EmptyCredential, fmt.Errorf("failed to read Docker config: %v", d.err)
+	}
+
+	cred, err := credentials.Credential(d.store)(ctx, registry)
+	if err != nil {
+		return auth.EmptyCredential, fmt.Errorf("failed to get Docker credentials for %s: %v", registry, err)
+	}
+	return cred, nil
+}
+
+// ACR exchanges an Azure AD access token for an Azure Container Registry
+// refresh token at the registry's /oauth2/exchange endpoint, as az acr login
+// does. Refresh tokens are reused until the Azure AD token expires.
+type ACR struct {
+	Credentials synapse.Credentials
+
+	mu     sync.Mutex
+	tokens map[string]acrToken
+}
+
+type acrToken struct {
+	refreshToken string
+	expiresOn    time.Time
+}
+
+// Credential implements CredentialProvider.
+func (a *ACR) Credential(ctx context.Context, registry string) (auth.Credential, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if t, ok := a.tokens[registry]; ok && time.Until(t.expiresOn) > time.Minute {
+		return auth.Credential{RefreshToken: t.refreshToken}, nil
+	}
+
+	cred, err := a.Credentials.TokenCredential()
+	if err != nil {
+		return auth.EmptyCredential, err
+	}
+	aad, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{a.Credentials.Cloud.ResourceManagerScope()}})
+	if err != nil {
+		return auth.EmptyCredential, fmt.Errorf("failed to get access token for %s: %v", registry, err)
+	}
+
+	refreshToken, err := exchangeACRToken(ctx, registry, a.Credentials.TenantID, aad.Token)
+	if err != nil {
+		return auth.EmptyCredential, err
+	}
+
+	if a.tokens == nil {
+		a.tokens = make(map[string]acrToken)
+	}
+	a.tokens[registry] = acrToken{refreshToken: refreshToken, expiresOn: aad.ExpiresOn}
+	return auth.Credential{RefreshToken: refreshToken}, nil
+}
+
+// exchangeACRToken trades an Azure AD access token for a refresh token of
+// the registry.
+func exchangeACRToken(ctx context.Context, registry, tenantID, accessToken string) (string, error) {
+	data := url.Values{}
+	data.Set("grant_type", "access_token")
+	data.Set("service", registry)
+	data.Set("access_token", accessToken)
+	if tenantID != "" {
+		data.Set("tenant", tenantID)
+	}
+
+	// The exchange has no side effects, so the POST may be retried
+	ctx = httpclient.WithIdempotent(ctx)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+registry+"/oauth2/exchange", bytes.NewBufferString(data.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to exchange token with %s: %s: %s", registry, resp.Status, body)
+	}
+
+	var result struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.RefreshToken == "" {
+		return "", fmt.Errorf("no refresh token in the response of %s", registry)
+	}
+	return result.RefreshToken, nil
+}
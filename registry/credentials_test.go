@@ -0,0 +1,252 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+
+	"github.com/utsavudhungana/artifactsrepo/httpclient"
+	"github.com/utsavudhungana/artifactsrepo/synapse"
+)
+
+// writeDockerConfig writes a Docker config.json with a login for
+// static.example.com and a credential helper for helped.example.com, and
+// points DOCKER_CONFIG at it.
+func writeDockerConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	login := base64.StdEncoding.EncodeToString([]byte("docker-user:docker-pass"))
+	config := `{
+	"auths": {"static.example.com": {"auth": "` + login + `"}},
+	"credHelpers": {"helped.example.com": "synapsectl-test"}
+}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_CONFIG", dir)
+	return dir
+}
+
+// installCredentialHelper puts a docker-credential-synapsectl-test helper on
+// PATH that answers every get with fixed credentials.
+func installCredentialHelper(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the test credential helper is a shell script")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\nread server\necho '{\"ServerURL\":\"'$server'\",\"Username\":\"helper-user\",\"Secret\":\"helper-pass\"}'\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker-credential-synapsectl-test"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestProviderForAuto(t *testing.T) {
+	writeDockerConfig(t)
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		registry string
+		want     auth.Credential
+	}{
+		{
+			name:     "environment first",
+			env:      map[string]string{"REGISTRY_USERNAME": "env-user", "REGISTRY_PASSWORD": "env-pass"},
+			registry: "static.example.com",
+			want:     auth.Credential{Username: "env-user", Password: "env-pass"},
+		},
+		{
+			name:     "docker config without environment",
+			registry: "static.example.com",
+			want:     auth.Credential{Username: "docker-user", Password: "docker-pass"},
+		},
+		{
+			name:     "anonymous without either",
+			registry: "other.example.com",
+			want:     auth.EmptyCredential,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("REGISTRY_USERNAME", tt.env["REGISTRY_USERNAME"])
+			t.Setenv("REGISTRY_PASSWORD", tt.env["REGISTRY_PASSWORD"])
+
+			p, err := ProviderFor(AuthAuto, synapse.Credentials{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.Credential(context.Background(), tt.registry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Credential(%s) = %+v, want %+v", tt.registry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderFor(t *testing.T) {
+	t.Setenv("REGISTRY_USERNAME", "")
+	t.Setenv("REGISTRY_PASSWORD", "")
+
+	tests := []struct {
+		mode    string
+		want    CredentialProvider
+		wantErr string
+	}{
+		{mode: AuthDocker, want: &DockerConfig{}},
+		{mode: AuthACR, want: &ACR{}},
+		{mode: AuthAnonymous, want: Anonymous{}},
+		{mode: AuthEnv, wantErr: "env registry auth needs REGISTRY_USERNAME and REGISTRY_PASSWORD"},
+		{mode: "basic", wantErr: `unknown registry auth mode "basic"`},
+	}
+	for _, tt := range tests {
+		got, err := ProviderFor(tt.mode, synapse.Credentials{})
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ProviderFor(%q) error = %v, want %q", tt.mode, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ProviderFor(%q): %v", tt.mode, err)
+			continue
+		}
+		if reflect.TypeOf(got) != reflect.TypeOf(tt.want) {
+			t.Errorf("ProviderFor(%q) = %T, want %T", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestDockerConfig(t *testing.T) {
+	writeDockerConfig(t)
+	installCredentialHelper(t)
+
+	tests := []struct {
+		registry string
+		want     auth.Credential
+	}{
+		{registry: "static.example.com", want: auth.Credential{Username: "docker-user", Password: "docker-pass"}},
+		{registry: "helped.example.com", want: auth.Credential{Username: "helper-user", Password: "helper-pass"}},
+		{registry: "other.example.com", want: auth.EmptyCredential},
+	}
+	d := &DockerConfig{}
+	for _, tt := range tests {
+		got, err := d.Credential(context.Background(), tt.registry)
+		if err != nil {
+			t.Errorf("Credential(%s): %v", tt.registry, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Credential(%s) = %+v, want %+v", tt.registry, got, tt.want)
+		}
+	}
+}
+
+func TestDockerConfigPath(t *testing.T) {
+	dir := writeDockerConfig(t)
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	d := &DockerConfig{Path: filepath.Join(dir, "config.json")}
+	got, err := d.Credential(context.Background(), "static.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Username != "docker-user" {
+		t.Errorf("Credential = %+v, want the login of %s", got, d.Path)
+	}
+
+	broken := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(broken, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&DockerConfig{Path: broken}).Credential(context.Background(), "static.example.com"); err == nil || !strings.Contains(err.Error(), "failed to read Docker config") {
+		t.Errorf("Credential with a broken config error = %v", err)
+	}
+}
+
+// useClient makes c the shared HTTP client for the rest of the test.
+func useClient(t *testing.T, c *http.Client) {
+	t.Helper()
+	previous := httpclient.Default()
+	t.Cleanup(func() { httpclient.SetDefault(previous) })
+	httpclient.SetDefault(c)
+}
+
+func TestExchangeACRToken(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/oauth2/exchange" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		form = r.PostForm
+		if form.Get("access_token") == "expired" {
+			http.Error(w, `{"errors":[{"code":"UNAUTHORIZED"}]}`, http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"refresh_token":"acr-refresh"}`))
+	}))
+	defer srv.Close()
+	useClient(t, srv.Client())
+	registry := srv.Listener.Addr().String()
+
+	token, err := exchangeACRToken(context.Background(), registry, "tenant", "aad-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "acr-refresh" {
+		t.Errorf("refresh token = %q, want acr-refresh", token)
+	}
+	want := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {registry},
+		"access_token": {"aad-token"},
+		"tenant":       {"tenant"},
+	}
+	if form.Encode() != want.Encode() {
+		t.Errorf("exchange form = %v, want %v", form, want)
+	}
+
+	if _, err := exchangeACRToken(context.Background(), registry, "", "expired"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("exchange of an expired token error = %v, want the 401", err)
+	}
+	if form.Has("tenant") {
+		t.Errorf("exchange without a tenant sent tenant=%q", form.Get("tenant"))
+	}
+}
+
+func TestACRReusesRefreshTokens(t *testing.T) {
+	// Getting a new token would fail: the credentials name no identity
+	a := &ACR{Credentials: synapse.Credentials{Auth: synapse.AuthClientSecret}}
+	a.tokens = map[string]acrToken{
+		"fresh.azurecr.io": {refreshToken: "cached", expiresOn: time.Now().Add(time.Hour)},
+		"stale.azurecr.io": {refreshToken: "stale", expiresOn: time.Now().Add(30 * time.Second)},
+	}
+
+	got, err := a.Credential(context.Background(), "fresh.azurecr.io")
+	if err != nil || got != (auth.Credential{RefreshToken: "cached"}) {
+		t.Errorf("Credential(fresh.azurecr.io) = %+v, %v, want the cached refresh token", got, err)
+	}
+	if _, err := a.Credential(context.Background(), "stale.azurecr.io"); err == nil {
+		t.Error("Credential(stale.azurecr.io) reused a token about to expire")
+	}
+}
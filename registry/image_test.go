@@ -0,0 +1,155 @@
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// pushJSON stores v as a blob of the given media type.
+func pushJSON(t *testing.T, store oras.Target, mediaType string, v any) ocispec.Descriptor {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pushBytes(t, store, mediaType, data)
+}
+
+func pushBytes(t *testing.T, store oras.Target, mediaType string, data []byte) ocispec.Descriptor {
+	t.Helper()
+	desc := content.NewDescriptorFromBytes(mediaType, data)
+	if err := pushBlob(context.Background(), store, desc, data); err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+// pushImage stores a single layer image holding /data/notebook/nb.json with
+// the given content, as push_dir.sh builds it, and returns its manifest.
+func pushImage(t *testing.T, store oras.Target, nb string) ocispec.Descriptor {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range []struct{ name, content string }{
+		{"bin/sh", "busybox"},
+		{"data/notebook/nb.json", nb},
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(f.content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	config := pushJSON(t, store, ocispec.MediaTypeImageConfig, ocispec.Image{Platform: ocispec.Platform{OS: "linux"}})
+	layer := pushBytes(t, store, ocispec.MediaTypeImageLayer, buf.Bytes())
+	m := ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, Config: config, Layers: []ocispec.Descriptor{layer}}
+	m.SchemaVersion = 2
+	return pushJSON(t, store, ocispec.MediaTypeImageManifest, m)
+}
+
+func TestPullImageIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		platforms []ocispec.Platform
+		want      string
+		wantErr   string
+	}{
+		{
+			name: "linux/amd64 preferred",
+			platforms: []ocispec.Platform{
+				{OS: "windows", Architecture: "amd64"},
+				{OS: "linux", Architecture: "arm64"},
+				{OS: "linux", Architecture: "amd64"},
+			},
+			want: "linux/amd64",
+		},
+		{
+			name: "first Linux image otherwise",
+			platforms: []ocispec.Platform{
+				{OS: "windows", Architecture: "amd64"},
+				{OS: "linux", Architecture: "arm64"},
+				{OS: "linux", Architecture: "s390x"},
+			},
+			want: "linux/arm64",
+		},
+		{
+			name:      "no Linux image",
+			platforms: []ocispec.Platform{{OS: "windows", Architecture: "amd64"}},
+			wantErr:   "lists no Linux image",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store, err := oci.New(filepath.Join(t.TempDir(), "layout"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			index := ocispec.Index{MediaType: ocispec.MediaTypeImageIndex}
+			index.SchemaVersion = 2
+			for _, p := range tt.platforms {
+				desc := pushImage(t, store, p.OS+"/"+p.Architecture)
+				desc.Platform = &p
+				index.Manifests = append(index.Manifests, desc)
+			}
+			desc := pushJSON(t, store, ocispec.MediaTypeImageIndex, index)
+			if err := store.Tag(ctx, desc, "v1"); err != nil {
+				t.Fatal(err)
+			}
+
+			files, err := PullFiles(ctx, store, "v1", nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("PullFiles error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 1 || string(files["notebook/nb.json"]) != tt.want {
+				t.Errorf("PullFiles = %q, want notebook/nb.json from the %s image", files, tt.want)
+			}
+		})
+	}
+}
+
+func TestPullImageAsPackage(t *testing.T) {
+	ctx := context.Background()
+	store, err := oci.New(filepath.Join(t.TempDir(), "layout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := pushImage(t, store, `{"name":"nb"}`)
+	if err := store.Tag(ctx, desc, "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := PullFiles(ctx, store, "v1", []string{"pipeline"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("PullFiles of pipelines = %q, want nothing from an image of a notebook", files)
+	}
+	if _, err := PullPackage(ctx, store, "v1"); err != nil {
+		t.Errorf("PullPackage of an image: %v", err)
+	}
+}
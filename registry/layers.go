@@ -0,0 +1,242 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/utsavudhungana/artifactsrepo/archive"
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/image"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+)
+
+const (
+	// MediaTypeArtifact is the media type of a layer holding one artifact
+	// definition in the layered layout.
+	MediaTypeArtifact = "application/vnd.synapse.artifact.v1+json"
+	// MediaTypeManifest is the media type of the package manifest layer in
+	// the layered layout.
+	MediaTypeManifest = "application/vnd.synapse.manifest.v1+json"
+)
+
+// Layouts of a package in a registry.
+const (
+	// LayoutZip stores the zipped package as a single layer.
+	LayoutZip = "zip"
+	// LayoutLayers stores each artifact as its own layer, titled with its
+	// path, so unchanged artifacts are shared by digest across pushes.
+	LayoutLayers = "layers"
+)
+
+// PushArtifacts stores the files of a package in target with one layer per
+// artifact and one for manifest.json, and tags the manifest unless tag is
+// empty. Layers target already holds are not uploaded again.
+func PushArtifacts(ctx context.Context, target oras.Target, tag string, files map[string][]byte) (ocispec.Descriptor, error) {
+	var layers []ocispec.Descriptor
+	for _, name := range archive.SortedNames(files) {
+		data := files[name]
+
+		var layer ocispec.Descriptor
+		switch {
+		case name == manifest.FileName:
+			layer = content.NewDescriptorFromBytes(MediaTypeManifest, data)
+			layer.Annotations = map[string]string{ocispec.AnnotationTitle: name}
+		case artifact.InLayout(name):
+			artifactType, err := artifact.TypeFromPath(name)
+			if err != nil {
+				continue
+			}
+			layer = content.NewDescriptorFromBytes(MediaTypeArtifact, data)
+			layer.Annotations = map[string]string{
+				ocispec.AnnotationTitle: name,
+				AnnotationArtifactType:  artifactType,
+			}
+		default:
+			continue
+		}
+
+		if err := pushBlob(ctx, target, layer, data); err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		layers = append(layers, layer)
+	}
+
+	desc, err := oras.PackManifest(ctx, target, oras.PackManifestVersion1_1, ArtifactType, oras.PackManifestOptions{
+		Layers:              layers,
+		ManifestAnnotations: manifestAnnotations(files),
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to push manifest: %v", err)
+	}
+
+	if tag != "" {
+		if err := target.Tag(ctx, desc, tag); err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to tag manifest as %s: %v", tag, err)
+		}
+	}
+	return desc, nil
+}
+
+// PullFiles fetches the files of the package that reference points at in
+// either layout, or below /data of a container image built by push_dir.sh.
+// When types is not empty only artifacts of those types are returned, and
+// in the layered layout only their layers are downloaded; the package
+// manifest is then left out, as it lists the whole package.
+func PullFiles(ctx context.Context, target oras.ReadOnlyTarget, reference string, types []string) (map[string][]byte, error) {
+	_, m, err := FetchManifest(ctx, target, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	if image.IsConfig(m.Config.MediaType) {
+		files, err := pullImage(ctx, target, m)
+		if err != nil {
+			return nil, err
+		}
+		return selectTypes(files, wanted), nil
+	}
+	if m.ArtifactType != ArtifactType {
+		return nil, fmt.Errorf("%s is not a Synapse artifact package (artifact type %q)", reference, m.ArtifactType)
+	}
+
+	files := make(map[string][]byte)
+	for _, layer := range m.Layers {
+		title := layer.Annotations[ocispec.AnnotationTitle]
+		switch layer.MediaType {
+		case MediaTypeZip:
+			data, err := content.FetchAll(ctx, target, layer)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch package layer %s: %v", layer.Digest, err)
+			}
+			zipped, err := archive.Unzip(data)
+			if err != nil {
+				return nil, err
+			}
+			for name, data := range zipped {
+				files[name] = data
+			}
+			continue
+		case MediaTypeManifest:
+			if len(wanted) > 0 {
+				continue
+			}
+		case MediaTypeArtifact:
+			if len(wanted) > 0 && !wanted[layer.Annotations[AnnotationArtifactType]] {
+				continue
+			}
+		default:
+			continue
+		}
+
+		if err := checkTitle(title); err != nil {
+			return nil, fmt.Errorf("layer %s: %v", layer.Digest, err)
+		}
+		data, err := content.FetchAll(ctx, target, layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %v", title, err)
+		}
+		files[title] = data
+	}
+
+	return selectTypes(files, wanted), nil
+}
+
+// selectTypes keeps the artifacts of the wanted types, or every file when
+// no type is wanted.
+func selectTypes(files map[string][]byte, wanted map[string]bool) map[string][]byte {
+	if len(wanted) == 0 {
+		return files
+	}
+	delete(files, manifest.FileName)
+	for name := range files {
+		if t, err := artifact.TypeFromPath(name); err != nil || !wanted[t] {
+			delete(files, name)
+		}
+	}
+	return files
+}
+
+// checkTitle refuses layer titles that are not clean relative paths.
+func checkTitle(title string) error {
+	if title == "" || strings.HasPrefix(title, "/") || strings.Contains(title, "\\") {
+		return fmt.Errorf("invalid title %q", title)
+	}
+	for _, part := range strings.Split(title, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid title %q", title)
+		}
+	}
+	return nil
+}
+
+// Change is a difference between two layered packages.
+type Change struct {
+	// Action is "+" for an added, "~" for a modified and "-" for a removed
+	// artifact.
+	Action string
+	Path   string
+}
+
+// Diff compares the artifacts of two layered packages by their layer
+// digests, reading only the two manifests.
+func Diff(ctx context.Context, from oras.ReadOnlyTarget, fromRef string, to oras.ReadOnlyTarget, toRef string) ([]Change, error) {
+	old, err := layerDigests(ctx, from, fromRef)
+	if err != nil {
+		return nil, err
+	}
+	current, err := layerDigests(ctx, to, toRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for title, digest := range current {
+		switch previous, ok := old[title]; {
+		case !ok:
+			changes = append(changes, Change{Action: "+", Path: title})
+		case previous != digest:
+			changes = append(changes, Change{Action: "~", Path: title})
+		}
+	}
+	for title := range old {
+		if _, ok := current[title]; !ok {
+			changes = append(changes, Change{Action: "-", Path: title})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// layerDigests maps the artifact layer titles of a layered package to
+// their digests.
+func layerDigests(ctx context.Context, target oras.ReadOnlyTarget, reference string) (map[string]string, error) {
+	_, m, err := FetchManifest(ctx, target, reference)
+	if err != nil {
+		return nil, err
+	}
+	if m.ArtifactType != ArtifactType {
+		return nil, fmt.Errorf("%s is not a Synapse artifact package (artifact type %q)", reference, m.ArtifactType)
+	}
+
+	digests := make(map[string]string)
+	for _, layer := range m.Layers {
+		switch layer.MediaType {
+		case MediaTypeZip:
+			return nil, fmt.Errorf("%s uses the %s layout; diffs need the %s layout", reference, LayoutZip, LayoutLayers)
+		case MediaTypeArtifact:
+			digests[layer.Annotations[ocispec.AnnotationTitle]] = layer.Digest.String()
+		}
+	}
+	return digests, nil
+}
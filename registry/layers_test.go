@@ -0,0 +1,246 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+
+	"github.com/utsavudhungana/artifactsrepo/archive"
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+	"github.com/utsavudhungana/artifactsrepo/packager"
+)
+
+// countingStore records the blobs pushed to and fetched from a target.
+type countingStore struct {
+	oras.Target
+
+	mu      sync.Mutex
+	pushed  []ocispec.Descriptor
+	fetched []ocispec.Descriptor
+}
+
+func (s *countingStore) Push(ctx context.Context, desc ocispec.Descriptor, r io.Reader) error {
+	s.mu.Lock()
+	s.pushed = append(s.pushed, desc)
+	s.mu.Unlock()
+	return s.Target.Push(ctx, desc, r)
+}
+
+func (s *countingStore) Fetch(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	s.mu.Lock()
+	s.fetched = append(s.fetched, desc)
+	s.mu.Unlock()
+	return s.Target.Fetch(ctx, desc)
+}
+
+// titles returns the sorted titles of the descriptors of a media type, and
+// forgets every descriptor seen so far.
+func (s *countingStore) titles(mediaType string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var titles []string
+	for _, desc := range append(s.pushed, s.fetched...) {
+		if desc.MediaType == mediaType {
+			titles = append(titles, desc.Annotations[ocispec.AnnotationTitle])
+		}
+	}
+	sort.Strings(titles)
+	s.pushed, s.fetched = nil, nil
+	return titles
+}
+
+func newCountingStore(t *testing.T) *countingStore {
+	t.Helper()
+	store, err := oci.New(filepath.Join(t.TempDir(), "layout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &countingStore{Target: store}
+}
+
+// packageFiles builds the files of a package of the given definitions,
+// keyed by path.
+func packageFiles(t *testing.T, defs map[string]string) map[string][]byte {
+	t.Helper()
+	var artifacts []artifact.Artifact
+	for p, def := range defs {
+		a, err := artifact.New(p, []byte(def))
+		if err != nil {
+			t.Fatal(err)
+		}
+		artifacts = append(artifacts, a)
+	}
+	data, err := packager.Build(artifacts, manifest.Source{Commit: "abc123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := archive.Unzip(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+var (
+	v1 = map[string]string{
+		"linkedService/ls_sql.json": `{"name":"ls_sql","properties":{"type":"AzureSqlDatabase"}}`,
+		"notebook/nb_a.json":        `{"name":"nb_a","properties":{"cells":[]}}`,
+		"pipeline/pl_load.json":     `{"name":"pl_load","properties":{"activities":[]}}`,
+	}
+	v2 = map[string]string{
+		"linkedService/ls_sql.json": `{"name":"ls_sql","properties":{"type":"AzureSqlDatabase"}}`,
+		"notebook/nb_b.json":        `{"name":"nb_b","properties":{"cells":[]}}`,
+		"pipeline/pl_load.json":     `{"name":"pl_load","properties":{"activities":[{"name":"Load"}]}}`,
+	}
+)
+
+func TestPushArtifactsSkipsUnchangedLayers(t *testing.T) {
+	ctx := context.Background()
+	store := newCountingStore(t)
+
+	if _, err := PushArtifacts(ctx, store, "v1", packageFiles(t, v1)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := store.titles(MediaTypeArtifact), []string{"linkedService/ls_sql.json", "notebook/nb_a.json", "pipeline/pl_load.json"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first push uploaded %v, want %v", got, want)
+	}
+
+	if _, err := PushArtifacts(ctx, store, "v2", packageFiles(t, v2)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := store.titles(MediaTypeArtifact), []string{"notebook/nb_b.json", "pipeline/pl_load.json"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second push uploaded %v, want only the changed artifacts %v", got, want)
+	}
+
+	// pushing the same package again uploads no layer at all
+	if _, err := PushArtifacts(ctx, store, "v2-again", packageFiles(t, v2)); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.titles(MediaTypeArtifact); len(got) != 0 {
+		t.Errorf("pushing an unchanged package uploaded %v", got)
+	}
+}
+
+func TestPullFilesByType(t *testing.T) {
+	ctx := context.Background()
+	store := newCountingStore(t)
+	files := packageFiles(t, v1)
+	if _, err := PushArtifacts(ctx, store, "v1", files); err != nil {
+		t.Fatal(err)
+	}
+	store.titles(MediaTypeArtifact)
+
+	tests := []struct {
+		types []string
+		want  []string
+	}{
+		{types: nil, want: []string{"linkedService/ls_sql.json", manifest.FileName, "notebook/nb_a.json", "pipeline/pl_load.json"}},
+		{types: []string{artifact.LinkedService}, want: []string{"linkedService/ls_sql.json"}},
+		{types: []string{artifact.Notebook, artifact.Pipeline}, want: []string{"notebook/nb_a.json", "pipeline/pl_load.json"}},
+		{types: []string{artifact.Trigger}, want: nil},
+	}
+	for _, tt := range tests {
+		got, err := PullFiles(ctx, store, "v1", tt.types)
+		if err != nil {
+			t.Fatalf("PullFiles(%v): %v", tt.types, err)
+		}
+		names := archive.SortedNames(got)
+		if len(names) == 0 {
+			names = nil
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("PullFiles(%v) = %v, want %v", tt.types, names, tt.want)
+		}
+		for _, name := range names {
+			if string(got[name]) != string(files[name]) {
+				t.Errorf("PullFiles(%v): %s = %q, want %q", tt.types, name, got[name], files[name])
+			}
+		}
+
+		// only the layers of the wanted types are downloaded
+		fetched := store.titles(MediaTypeArtifact)
+		var wantFetched []string
+		for _, name := range tt.want {
+			if name != manifest.FileName {
+				wantFetched = append(wantFetched, name)
+			}
+		}
+		if !reflect.DeepEqual(fetched, wantFetched) {
+			t.Errorf("PullFiles(%v) fetched %v, want %v", tt.types, fetched, wantFetched)
+		}
+	}
+}
+
+func TestPullTypesFromLayout(t *testing.T) {
+	ctx := context.Background()
+	data, err := archive.Zip(packageFiles(t, v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, layout := range []string{LayoutZip, LayoutLayers} {
+		reference := LayoutPrefix + filepath.Join(t.TempDir(), "layout") + ":v1"
+		if _, err := Push(ctx, reference, data, layout, Credentials{}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := PullTypes(ctx, reference, []string{artifact.Pipeline}, Credentials{})
+		if err != nil {
+			t.Fatalf("%s: PullTypes: %v", layout, err)
+		}
+		if names := archive.SortedNames(got); !reflect.DeepEqual(names, []string{"pipeline/pl_load.json"}) {
+			t.Errorf("%s: PullTypes = %v, want only the pipeline", layout, names)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	ctx := context.Background()
+	store := newCountingStore(t)
+	for tag, defs := range map[string]map[string]string{"v1": v1, "v2": v2} {
+		if _, err := PushArtifacts(ctx, store, tag, packageFiles(t, defs)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.titles(MediaTypeArtifact)
+
+	changes, err := Diff(ctx, store, "v1", store, "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Action: "-", Path: "notebook/nb_a.json"},
+		{Action: "+", Path: "notebook/nb_b.json"},
+		{Action: "~", Path: "pipeline/pl_load.json"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Diff = %v, want %v", changes, want)
+	}
+	if fetched := store.titles(MediaTypeArtifact); len(fetched) != 0 {
+		t.Errorf("Diff downloaded %v, want only the manifests read", fetched)
+	}
+
+	if changes, err := Diff(ctx, store, "v2", store, "v2"); err != nil || len(changes) != 0 {
+		t.Errorf("Diff of a package with itself = %v, %v", changes, err)
+	}
+
+	zipped, err := archive.Zip(packageFiles(t, v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PushPackage(ctx, store, "zip", zipped); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Diff(ctx, store, "zip", store, "v2"); err == nil || !strings.Contains(err.Error(), "uses the zip layout") {
+		t.Errorf("Diff of a zip package error = %v, want a layout error", err)
+	}
+}
@@ -0,0 +1,297 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/utsavudhungana/artifactsrepo/archive"
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/image"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+const (
+	// ArtifactType is the artifactType of package manifests.
+	ArtifactType = "application/vnd.synapse.artifacts.v1"
+	// MediaTypeZip is the media type of zipped artifact packages.
+	MediaTypeZip = "application/vnd.zip"
+	// PackageFileName is the title of the package layer.
+	PackageFileName = "artifacts.zip"
+	// AnnotationArtifactType lists the Synapse artifact types a layer holds,
+	// comma separated.
+	AnnotationArtifactType = "io.github.utsavudhungana.synapse.artifact.type"
+	// DefaultTag is pushed when a reference names no tag.
+	DefaultTag = "latest"
+)
+
+// PushPackage stores a zipped package in target as the single layer of an
+// OCI manifest with ArtifactType, and tags the manifest unless tag is empty.
+// The layer is annotated with its file name and the artifact types it
+// holds; the manifest with the package's source and commit when the package
+// has a manifest.json.
+func PushPackage(ctx context.Context, target oras.Target, tag string, data []byte) (ocispec.Descriptor, error) {
+	files, err := archive.Unzip(data)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("package is not a valid zip: %v", err)
+	}
+
+	layer := content.NewDescriptorFromBytes(MediaTypeZip, data)
+	layer.Annotations = map[string]string{
+		ocispec.AnnotationTitle: PackageFileName,
+		AnnotationArtifactType:  strings.Join(artifactTypes(files), ","),
+	}
+	if err := pushBlob(ctx, target, layer, data); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	desc, err := oras.PackManifest(ctx, target, oras.PackManifestVersion1_1, ArtifactType, oras.PackManifestOptions{
+		Layers:              []ocispec.Descriptor{layer},
+		ManifestAnnotations: manifestAnnotations(files),
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to push manifest: %v", err)
+	}
+
+	if tag != "" {
+		if err := target.Tag(ctx, desc, tag); err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to tag manifest as %s: %v", tag, err)
+		}
+	}
+	return desc, nil
+}
+
+// pushBlob uploads a blob unless target already has it.
+func pushBlob(ctx context.Context, target oras.Target, desc ocispec.Descriptor, data []byte) error {
+	exists, err := target.Exists(ctx, desc)
+	if err != nil {
+		return fmt.Errorf("failed to check blob %s: %v", desc.Digest, err)
+	}
+	if exists {
+		return nil
+	}
+	if err := target.Push(ctx, desc, bytes.NewReader(data)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return fmt.Errorf("failed to push blob %s: %v", desc.Digest, err)
+	}
+	return nil
+}
+
+// artifactTypes returns the sorted artifact types of the package files.
+func artifactTypes(files map[string][]byte) []string {
+	seen := make(map[string]bool)
+	for name := range files {
+		if t, err := artifact.TypeFromPath(name); err == nil && artifact.InLayout(name) {
+			seen[t] = true
+		}
+	}
+	types := make([]string, 0, len(seen))
+	for t := range seen {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// manifestAnnotations records the provenance of the package manifest, if
+// any, in the standard OCI annotations.
+func manifestAnnotations(files map[string][]byte) map[string]string {
+	data, ok := files[manifest.FileName]
+	if !ok {
+		return nil
+	}
+	m, err := manifest.Parse(data)
+	if err != nil {
+		return nil
+	}
+
+	annotations := make(map[string]string)
+	if m.Source.Repository != "" {
+		annotations[ocispec.AnnotationSource] = m.Source.Repository
+	}
+	if m.Source.Commit != "" {
+		annotations[ocispec.AnnotationRevision] = m.Source.Commit
+	}
+	return annotations
+}
+
+// FetchManifest resolves reference, a tag or digest, and returns the
+// descriptor and decoded image manifest it points at. Docker image
+// manifests are read the same way, and an image index or Docker manifest
+// list resolves to the manifest of its Linux image.
+func FetchManifest(ctx context.Context, target oras.ReadOnlyTarget, reference string) (ocispec.Descriptor, ocispec.Manifest, error) {
+	var m ocispec.Manifest
+	if reference == "" {
+		return ocispec.Descriptor{}, m, fmt.Errorf("reference needs a tag or digest")
+	}
+
+	desc, err := target.Resolve(ctx, reference)
+	if err != nil {
+		return ocispec.Descriptor{}, m, fmt.Errorf("failed to resolve %s: %v", reference, err)
+	}
+	if desc.MediaType == ocispec.MediaTypeImageIndex || desc.MediaType == image.MediaTypeDockerManifestList {
+		desc, err = platformManifest(ctx, target, desc)
+		if err != nil {
+			return ocispec.Descriptor{}, m, fmt.Errorf("%s: %v", reference, err)
+		}
+	}
+	if desc.MediaType != ocispec.MediaTypeImageManifest && desc.MediaType != image.MediaTypeDockerManifest {
+		return ocispec.Descriptor{}, m, fmt.Errorf("%s is a %s, not an image manifest", reference, desc.MediaType)
+	}
+
+	data, err := content.FetchAll(ctx, target, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, m, fmt.Errorf("failed to fetch manifest %s: %v", desc.Digest, err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return ocispec.Descriptor{}, m, fmt.Errorf("failed to parse manifest %s: %v", desc.Digest, err)
+	}
+	return desc, m, nil
+}
+
+// platformManifest picks the image of an index: linux/amd64 if listed,
+// otherwise the first Linux one. The files below /data do not depend on
+// the platform.
+func platformManifest(ctx context.Context, target oras.ReadOnlyTarget, desc ocispec.Descriptor) (ocispec.Descriptor, error) {
+	data, err := content.FetchAll(ctx, target, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to fetch index %s: %v", desc.Digest, err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to parse index %s: %v", desc.Digest, err)
+	}
+
+	var found *ocispec.Descriptor
+	for i, m := range index.Manifests {
+		if m.Platform == nil || m.Platform.OS != "linux" {
+			continue
+		}
+		if m.Platform.Architecture == "amd64" {
+			return m, nil
+		}
+		if found == nil {
+			found = &index.Manifests[i]
+		}
+	}
+	if found == nil {
+		return ocispec.Descriptor{}, fmt.Errorf("index %s lists no Linux image", desc.Digest)
+	}
+	return *found, nil
+}
+
+// pullImage returns the files below image.DefaultRoot of a container image,
+// as push_dir.sh lays them out, by applying its layers in order.
+func pullImage(ctx context.Context, target oras.ReadOnlyTarget, m ocispec.Manifest) (map[string][]byte, error) {
+	fs := image.NewFilesystem(image.DefaultRoot)
+	for _, layer := range m.Layers {
+		data, err := content.FetchAll(ctx, target, layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch image layer %s: %v", layer.Digest, err)
+		}
+		if err := fs.Apply(data); err != nil {
+			return nil, fmt.Errorf("image layer %s: %v", layer.Digest, err)
+		}
+	}
+	return fs.Files(), nil
+}
+
+// PullPackage fetches the zipped package that reference, a tag or digest,
+// points at in target. A package in the layered layout, or a container image
+// built by push_dir.sh, is zipped from its layers. Digests are verified
+// while fetching.
+func PullPackage(ctx context.Context, target oras.ReadOnlyTarget, reference string) ([]byte, error) {
+	_, m, err := FetchManifest(ctx, target, reference)
+	if err != nil {
+		return nil, err
+	}
+	if image.IsConfig(m.Config.MediaType) {
+		files, err := pullImage(ctx, target, m)
+		if err != nil {
+			return nil, err
+		}
+		return archive.Zip(files)
+	}
+	if m.ArtifactType != ArtifactType {
+		return nil, fmt.Errorf("%s is not a Synapse artifact package (artifact type %q)", reference, m.ArtifactType)
+	}
+
+	for _, layer := range m.Layers {
+		if layer.MediaType != MediaTypeZip {
+			continue
+		}
+		data, err := content.FetchAll(ctx, target, layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch package layer %s: %v", layer.Digest, err)
+		}
+		return data, nil
+	}
+
+	for _, layer := range m.Layers {
+		if layer.MediaType != MediaTypeArtifact {
+			continue
+		}
+		files, err := PullFiles(ctx, target, reference, nil)
+		if err != nil {
+			return nil, err
+		}
+		return archive.Zip(files)
+	}
+	return nil, fmt.Errorf("%s has no package layer", reference)
+}
+
+// Push uploads a zipped package in the given layout, LayoutZip or
+// LayoutLayers, to the registry or OCI layout named by reference, tagging it
+// with the reference's tag or DefaultTag.
+func Push(ctx context.Context, reference string, data []byte, layout string, cred CredentialProvider) (ocispec.Descriptor, error) {
+	target, tag, err := Open(reference, cred)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if tag == "" {
+		tag = DefaultTag
+	}
+	if strings.Contains(tag, ":") {
+		return ocispec.Descriptor{}, fmt.Errorf("cannot push to digest reference %s", reference)
+	}
+
+	switch layout {
+	case LayoutZip, "":
+		return PushPackage(ctx, target, tag, data)
+	case LayoutLayers:
+		files, err := archive.Unzip(data)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		return PushArtifacts(ctx, target, tag, files)
+	default:
+		return ocispec.Descriptor{}, fmt.Errorf("unknown package layout %q (want %s or %s)", layout, LayoutZip, LayoutLayers)
+	}
+}
+
+// Pull downloads the package that reference names by tag or digest, for
+// example myacr.azurecr.io/synapse/artifacts:v1.
+func Pull(ctx context.Context, reference string, cred CredentialProvider) ([]byte, error) {
+	target, ref, err := Open(reference, cred)
+	if err != nil {
+		return nil, err
+	}
+	return PullPackage(ctx, target, ref)
+}
+
+// PullTypes downloads the files of the package that reference names,
+// limited to artifacts of the given types; see PullFiles.
+func PullTypes(ctx context.Context, reference string, types []string, cred CredentialProvider) (map[string][]byte, error) {
+	target, ref, err := Open(reference, cred)
+	if err != nil {
+		return nil, err
+	}
+	return PullFiles(ctx, target, ref, types)
+}
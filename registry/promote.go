@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+)
+
+const (
+	// ArtifactTypePromotion is the artifactType of promotion records.
+	ArtifactTypePromotion = "application/vnd.synapse.promotion.v1"
+	// AnnotationEnvironment names the environment a package was promoted to.
+	AnnotationEnvironment = "io.github.utsavudhungana.synapse.promotion.environment"
+	// AnnotationPromotedBy names who promoted a package.
+	AnnotationPromotedBy = "io.github.utsavudhungana.synapse.promotion.by"
+	// AnnotationPromotedFrom is the reference a package was promoted from.
+	AnnotationPromotedFrom = "io.github.utsavudhungana.synapse.promotion.from"
+)
+
+// Promotion describes a promotion of a package to an environment.
+type Promotion struct {
+	// Environment is recorded and pushed as a tag.
+	Environment string
+	// By and At record who promoted the package and when.
+	By string
+	At time.Time
+	// From is the source reference recorded with the promotion.
+	From string
+	// Tags are pushed in addition to Environment.
+	Tags []string
+}
+
+// Promote copies the package that srcRef, a tag or digest, names in src to
+// dst by digest, with its layers and every referrer such as signatures and
+// earlier promotion records. The package manifest is not modified: the
+// promotion is recorded as a referrer annotated with the environment, who
+// and when, and the package is tagged with the environment and p.Tags.
+func Promote(ctx context.Context, src oras.ReadOnlyTarget, srcRef string, dst oras.Target, p Promotion) (ocispec.Descriptor, error) {
+	graph, ok := src.(oras.ReadOnlyGraphTarget)
+	if !ok {
+		return ocispec.Descriptor{}, fmt.Errorf("the source store does not list referrers")
+	}
+
+	desc, m, err := FetchManifest(ctx, src, srcRef)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if m.ArtifactType != ArtifactType {
+		return ocispec.Descriptor{}, fmt.Errorf("%s is not a Synapse artifact package (artifact type %q)", srcRef, m.ArtifactType)
+	}
+
+	if err := oras.ExtendedCopyGraph(ctx, graph, dst, desc, oras.DefaultExtendedCopyGraphOptions); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to copy %s: %v", desc.Digest, err)
+	}
+
+	_, err = oras.PackManifest(ctx, dst, oras.PackManifestVersion1_1, ArtifactTypePromotion, oras.PackManifestOptions{
+		Subject: &desc,
+		ManifestAnnotations: map[string]string{
+			AnnotationEnvironment:     p.Environment,
+			AnnotationPromotedBy:      p.By,
+			AnnotationPromotedFrom:    p.From,
+			ocispec.AnnotationCreated: p.At.UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to record promotion: %v", err)
+	}
+
+	for _, tag := range append(p.Tags, p.Environment) {
+		if err := dst.Tag(ctx, desc, tag); err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to tag %s as %s: %v", desc.Digest, tag, err)
+		}
+	}
+	return desc, nil
+}
@@ -0,0 +1,215 @@
+package registry
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/oci"
+	orasregistry "oras.land/oras-go/v2/registry"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+	"github.com/utsavudhungana/artifactsrepo/signature"
+)
+
+func newLayout(t *testing.T, name string) *oci.Store {
+	t.Helper()
+	store, err := oci.New(filepath.Join(t.TempDir(), name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// promotions returns the annotations of the promotion records of subject.
+func promotions(t *testing.T, ctx context.Context, store *oci.Store, subject ocispec.Descriptor) []map[string]string {
+	t.Helper()
+	referrers, err := orasregistry.Referrers(ctx, store, subject, ArtifactTypePromotion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]string
+	for _, r := range referrers {
+		_, m, err := FetchManifest(ctx, store, r.Digest.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, m.Annotations)
+	}
+	return records
+}
+
+// assertPromoted checks that dst holds the package desc names with all its
+// layers, verifiable against its manifest, under every tag.
+func assertPromoted(t *testing.T, ctx context.Context, dst *oci.Store, desc ocispec.Descriptor, tags ...string) {
+	t.Helper()
+	for _, tag := range tags {
+		got, err := dst.Resolve(ctx, tag)
+		if err != nil {
+			t.Fatalf("Resolve(%s): %v", tag, err)
+		}
+		if got.Digest != desc.Digest {
+			t.Errorf("%s resolves to %s, want the promoted package %s", tag, got.Digest, desc.Digest)
+		}
+	}
+
+	_, m, err := FetchManifest(ctx, dst, desc.Digest.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, layer := range m.Layers {
+		if ok, err := dst.Exists(ctx, layer); err != nil || !ok {
+			t.Errorf("layer %s (%s) was not copied", layer.Digest, layer.Annotations[ocispec.AnnotationTitle])
+		}
+	}
+
+	files, err := PullFiles(ctx, dst, desc.Digest.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifacts, _, err := artifact.FromFiles(files, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mf, err := manifest.Parse(files[manifest.FileName])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mf.Verify(artifacts); err != nil {
+		t.Errorf("promoted package does not match its manifest: %v", err)
+	}
+}
+
+func TestPromote(t *testing.T) {
+	ctx := context.Background()
+	src := newLayout(t, "src")
+
+	desc, err := PushArtifacts(ctx, src, "v1", packageFiles(t, v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := PullFiles(ctx, src, "v1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := signature.ManifestDigest(files[manifest.FileName])
+	key, err := signature.LoadPrivateKey(writeKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signature.Sign(key, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := sig.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigDesc, err := AttachSignature(ctx, src, desc, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// promote to test, then from there to prod, which carries both records
+	at := time.Date(2026, 10, 16, 9, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	test := newLayout(t, "test")
+	got, err := Promote(ctx, src, "v1", test, Promotion{Environment: "test", By: "alice", At: at, From: "oci:src:v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Digest != desc.Digest {
+		t.Fatalf("Promote returned %s, want the unchanged manifest %s", got.Digest, desc.Digest)
+	}
+
+	prod := newLayout(t, "prod")
+	if _, err := Promote(ctx, test, "test", prod, Promotion{Environment: "prod", By: "release-bot", At: at.Add(time.Hour), From: "oci:test:test", Tags: []string{"prod-20261016"}}); err != nil {
+		t.Fatal(err)
+	}
+	assertPromoted(t, ctx, test, desc, "test")
+	assertPromoted(t, ctx, prod, desc, "prod", "prod-20261016")
+
+	if ok, err := prod.Exists(ctx, sigDesc); err != nil || !ok {
+		t.Errorf("signature manifest %s was not copied", sigDesc.Digest)
+	}
+	docs, err := Signatures(ctx, prod, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("prod holds %d signatures, want 1", len(docs))
+	}
+	copied, err := signature.Parse(docs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := copied.Verify(key.Public(), digest); err != nil {
+		t.Errorf("copied signature does not verify: %v", err)
+	}
+
+	want := map[string]map[string]string{
+		"test": {
+			AnnotationEnvironment:     "test",
+			AnnotationPromotedBy:      "alice",
+			AnnotationPromotedFrom:    "oci:src:v1",
+			ocispec.AnnotationCreated: "2026-10-16T07:30:00Z",
+		},
+		"prod": {
+			AnnotationEnvironment:     "prod",
+			AnnotationPromotedBy:      "release-bot",
+			AnnotationPromotedFrom:    "oci:test:test",
+			ocispec.AnnotationCreated: "2026-10-16T08:30:00Z",
+		},
+	}
+	records := promotions(t, ctx, prod, desc)
+	if len(records) != 2 {
+		t.Fatalf("prod holds %d promotion records, want the test and prod ones", len(records))
+	}
+	for _, record := range records {
+		env := record[AnnotationEnvironment]
+		if !reflect.DeepEqual(record, want[env]) {
+			t.Errorf("%s promotion record = %v, want %v", env, record, want[env])
+		}
+	}
+}
+
+func TestPromoteWithoutReferrers(t *testing.T) {
+	ctx := context.Background()
+	src := newLayout(t, "src")
+	desc, err := PushArtifacts(ctx, src, "v1", packageFiles(t, v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := newLayout(t, "dst")
+	if _, err := Promote(ctx, src, desc.Digest.String(), dst, Promotion{Environment: "dev", By: "bob", At: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	assertPromoted(t, ctx, dst, desc, "dev")
+
+	docs, err := Signatures(ctx, dst, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 0 {
+		t.Errorf("dst holds %d signatures of an unsigned package", len(docs))
+	}
+	if records := promotions(t, ctx, dst, desc); len(records) != 1 || records[0][AnnotationPromotedBy] != "bob" {
+		t.Errorf("promotion records = %v, want the one by bob", records)
+	}
+}
+
+func TestPromoteRefusesImages(t *testing.T) {
+	ctx := context.Background()
+	src := newLayout(t, "src")
+	desc := pushImage(t, src, `{"name":"nb"}`)
+
+	_, err := Promote(ctx, src, desc.Digest.String(), newLayout(t, "dst"), Promotion{Environment: "dev"})
+	if err == nil || !strings.Contains(err.Error(), "is not a Synapse artifact package") {
+		t.Errorf("Promote error = %v, want a refusal", err)
+	}
+}
@@ -0,0 +1,71 @@
+// Package registry moves artifact packages in and out of OCI registries such
+// as Azure Container Registry, and of OCI image layouts on disk.
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/httpclient"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// LayoutPrefix marks a reference to an OCI image layout directory instead
+// of a registry, for example oci:build/layout:v1.
+const LayoutPrefix = "oci:"
+
+// Open returns the store named by reference and the tag or digest it
+// addresses, which is empty when the reference names none. A reference is
+// either registry/repository[:tag|@digest], such as
+// myacr.azurecr.io/synapse/artifacts:v1, or an OCI image layout directory
+// written oci:path[:tag|@digest].
+func Open(reference string, cred CredentialProvider) (oras.Target, string, error) {
+	if strings.HasPrefix(reference, LayoutPrefix) {
+		dir, ref := splitLayoutReference(strings.TrimPrefix(reference, LayoutPrefix))
+		store, err := oci.New(dir)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open OCI layout %s: %v", dir, err)
+		}
+		return store, ref, nil
+	}
+
+	repo, err := newRepository(reference, cred)
+	if err != nil {
+		return nil, "", err
+	}
+	return repo, repo.Reference.Reference, nil
+}
+
+// splitLayoutReference splits path[:tag|@digest].
+func splitLayoutReference(s string) (dir, ref string) {
+	if i := strings.LastIndex(s, "@"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	if i := strings.LastIndex(s, ":"); i >= 0 && !strings.ContainsAny(s[i:], `/\`) {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+// newRepository opens the repository named by reference, for example
+// myacr.azurecr.io/synapse/artifacts.
+func newRepository(reference string, cred CredentialProvider) (*remote.Repository, error) {
+	repo, err := remote.NewRepository(reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repository reference: %v", err)
+	}
+
+	if cred == nil {
+		cred = Anonymous{}
+	}
+	repo.Client = &auth.Client{
+		Client:     httpclient.Default(),
+		Cache:      auth.NewCache(),
+		Credential: cred.Credential,
+	}
+
+	return repo, nil
+}
@@ -0,0 +1,241 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/archive"
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+	"github.com/utsavudhungana/artifactsrepo/packager"
+	"github.com/utsavudhungana/artifactsrepo/signature"
+)
+
+// testPackage builds a zipped package of a linked service and a pipeline.
+func testPackage(t *testing.T) []byte {
+	t.Helper()
+	var artifacts []artifact.Artifact
+	for p, def := range map[string]string{
+		"linkedService/ls_sql.json": `{"name":"ls_sql","properties":{"type":"AzureSqlDatabase"}}`,
+		"pipeline/pl_load.json":     `{"name":"pl_load","properties":{"activities":[]}}`,
+	} {
+		a, err := artifact.New(p, []byte(def))
+		if err != nil {
+			t.Fatal(err)
+		}
+		artifacts = append(artifacts, a)
+	}
+	data, err := packager.Build(artifacts, manifest.Source{Repository: "https://example.com/repo.git", Commit: "abc123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// pullVerified pulls the package reference names and checks its artifacts
+// against its manifest, returning the pulled files.
+func pullVerified(t *testing.T, ctx context.Context, reference string) (map[string][]byte, error) {
+	t.Helper()
+	data, err := Pull(ctx, reference, Credentials{})
+	if err != nil {
+		t.Fatalf("Pull(%s): %v", reference, err)
+	}
+	files, err := archive.Unzip(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifacts, _, err := artifact.FromFiles(files, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := manifest.Parse(files[manifest.FileName])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files, m.Verify(artifacts)
+}
+
+func TestPushPull(t *testing.T) {
+	ctx := context.Background()
+	data := testPackage(t)
+	want, err := archive.Unzip(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, layout := range []string{LayoutZip, LayoutLayers} {
+		t.Run(layout, func(t *testing.T) {
+			reference := LayoutPrefix + filepath.Join(t.TempDir(), "layout") + ":v1"
+			desc, err := Push(ctx, reference, data, layout, Credentials{})
+			if err != nil {
+				t.Fatalf("Push: %v", err)
+			}
+
+			for _, ref := range []string{reference, strings.TrimSuffix(reference, ":v1") + "@" + desc.Digest.String()} {
+				got, err := pullVerified(t, ctx, ref)
+				if err != nil {
+					t.Fatalf("package pulled by %s does not verify: %v", ref, err)
+				}
+				if len(got) != len(want) {
+					t.Fatalf("pulled %d files by %s, want %d", len(got), ref, len(want))
+				}
+				for name, content := range want {
+					if !bytes.Equal(got[name], content) {
+						t.Errorf("%s: pulled %q by %s, want %q", name, got[name], ref, content)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPushDigestReference(t *testing.T) {
+	reference := LayoutPrefix + t.TempDir() + "@sha256:" + strings.Repeat("0", 64)
+	_, err := Push(context.Background(), reference, testPackage(t), LayoutZip, Credentials{})
+	if err == nil || !strings.Contains(err.Error(), "cannot push to digest reference") {
+		t.Fatalf("Push to a digest error = %v", err)
+	}
+}
+
+func TestTamperedPackage(t *testing.T) {
+	ctx := context.Background()
+	files, err := archive.Unzip(testPackage(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["pipeline/pl_load.json"] = []byte(`{"name":"pl_load","properties":{"activities":[{"name":"exfiltrate"}]}}`)
+	tampered, err := archive.Zip(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reference := LayoutPrefix + filepath.Join(t.TempDir(), "layout") + ":v1"
+	if _, err := Push(ctx, reference, tampered, LayoutZip, Credentials{}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = pullVerified(t, ctx, reference)
+	if err == nil || !strings.Contains(err.Error(), "pipeline/pl_load does not match its manifest checksum") {
+		t.Fatalf("verifying a tampered artifact: error = %v", err)
+	}
+}
+
+// writeKey stores a new ed25519 private key as a PKCS #8 PEM file.
+func writeKey(t *testing.T) string {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return keyPath
+}
+
+func TestSignatures(t *testing.T) {
+	ctx := context.Background()
+	reference := LayoutPrefix + filepath.Join(t.TempDir(), "layout") + ":v1"
+	if _, err := Push(ctx, reference, testPackage(t), LayoutLayers, Credentials{}); err != nil {
+		t.Fatal(err)
+	}
+	target, tag, err := Open(reference, Credentials{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, files, err := ResolvePackage(ctx, target, tag, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := signature.ManifestDigest(files[manifest.FileName])
+
+	key, err := signature.LoadPrivateKey(writeKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signature.Sign(key, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := sig.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AttachSignature(ctx, target, desc, doc); err != nil {
+		t.Fatalf("AttachSignature: %v", err)
+	}
+
+	docs, err := Signatures(ctx, target, desc)
+	if err != nil {
+		t.Fatalf("Signatures: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("found %d signatures, want 1", len(docs))
+	}
+	got, err := signature.Parse(docs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.Verify(key.Public(), digest); err != nil {
+		t.Errorf("Verify with the signing key: %v", err)
+	}
+
+	other, err := signature.LoadPrivateKey(writeKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.Verify(other.Public(), digest); err == nil {
+		t.Error("Verify with another key succeeded")
+	}
+}
+
+func TestTamperedManifest(t *testing.T) {
+	ctx := context.Background()
+	files, err := archive.Unzip(testPackage(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := signature.LoadPrivateKey(writeKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signature.Sign(key, signature.ManifestDigest(files[manifest.FileName]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := make(map[string][]byte, len(files))
+	for name, content := range files {
+		tampered[name] = content
+	}
+	tampered[manifest.FileName] = bytes.Replace(files[manifest.FileName], []byte("abc123"), []byte("def456"), 1)
+
+	reference := LayoutPrefix + filepath.Join(t.TempDir(), "layout") + ":v1"
+	zipped, err := archive.Zip(tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Push(ctx, reference, zipped, LayoutZip, Credentials{}); err != nil {
+		t.Fatal(err)
+	}
+
+	pulled, err := pullVerified(t, ctx, reference)
+	if err != nil {
+		t.Fatalf("the artifacts themselves are untouched: %v", err)
+	}
+	if err := sig.Verify(key.Public(), signature.ManifestDigest(pulled[manifest.FileName])); err == nil {
+		t.Fatal("signature verified against a tampered manifest")
+	}
+}
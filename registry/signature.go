@@ -0,0 +1,82 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	orasregistry "oras.land/oras-go/v2/registry"
+
+	"github.com/utsavudhungana/artifactsrepo/signature"
+)
+
+// ResolvePackage resolves reference, a tag or digest, and returns the
+// manifest descriptor with the package files it points at, so callers can
+// tie what they read to one digest.
+func ResolvePackage(ctx context.Context, target oras.ReadOnlyTarget, reference string, types []string) (ocispec.Descriptor, map[string][]byte, error) {
+	if reference == "" {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("reference needs a tag or digest")
+	}
+	desc, err := target.Resolve(ctx, reference)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("failed to resolve %s: %v", reference, err)
+	}
+
+	files, err := PullFiles(ctx, target, desc.Digest.String(), types)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	return desc, files, nil
+}
+
+// AttachSignature stores a signature document as an OCI referrer of
+// subject: an artifact manifest with artifactType signature.MediaType whose
+// subject is the package manifest.
+func AttachSignature(ctx context.Context, target oras.Target, subject ocispec.Descriptor, sig []byte) (ocispec.Descriptor, error) {
+	layer := content.NewDescriptorFromBytes(signature.MediaType, sig)
+	if err := pushBlob(ctx, target, layer, sig); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	desc, err := oras.PackManifest(ctx, target, oras.PackManifestVersion1_1, signature.MediaType, oras.PackManifestOptions{
+		Subject: &subject,
+		Layers:  []ocispec.Descriptor{layer},
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to push signature manifest: %v", err)
+	}
+	return desc, nil
+}
+
+// Signatures fetches the signature documents attached to subject.
+func Signatures(ctx context.Context, target oras.ReadOnlyTarget, subject ocispec.Descriptor) ([][]byte, error) {
+	graph, ok := target.(content.ReadOnlyGraphStorage)
+	if !ok {
+		return nil, fmt.Errorf("the store does not list referrers")
+	}
+	referrers, err := orasregistry.Referrers(ctx, graph, subject, signature.MediaType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signatures of %s: %v", subject.Digest, err)
+	}
+
+	var sigs [][]byte
+	for _, referrer := range referrers {
+		_, m, err := FetchManifest(ctx, target, referrer.Digest.String())
+		if err != nil {
+			return nil, err
+		}
+		for _, layer := range m.Layers {
+			if layer.MediaType != signature.MediaType {
+				continue
+			}
+			data, err := content.FetchAll(ctx, target, layer)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch signature %s: %v", layer.Digest, err)
+			}
+			sigs = append(sigs, data)
+		}
+	}
+	return sigs, nil
+}
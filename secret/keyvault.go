@@ -0,0 +1,86 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+	"github.com/utsavudhungana/artifactsrepo/httpclient"
+	"github.com/utsavudhungana/artifactsrepo/synapse"
+)
+
+// KeyVaultAPIVersion is the Key Vault data-plane API version.
+const KeyVaultAPIVersion = "7.4"
+
+// KeyVault resolves ${keyvault:vault/secret} and
+// ${keyvault:vault/secret/version} through the Key Vault REST API. The
+// credential is created on first use, so runs without Key Vault placeholders
+// never authenticate.
+type KeyVault struct {
+	Credentials synapse.Credentials
+
+	once sync.Once
+	cred azcore.TokenCredential
+	err  error
+}
+
+// Resolve implements Resolver.
+func (k *KeyVault) Resolve(ctx context.Context, ref string) (string, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("Key Vault reference %q is not vault/secret[/version]", ref)
+	}
+
+	k.once.Do(func() { k.cred, k.err = k.Credentials.TokenCredential() })
+	if k.err != nil {
+		return "", k.err
+	}
+
+	cloud := k.Credentials.Cloud
+	token, err := k.cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{cloud.VaultScope()}})
+	if err != nil {
+		return "", fmt.Errorf("failed to get Key Vault access token: %v", err)
+	}
+
+	secretURL := cloud.VaultURL(parts[0]) + "/secrets/" + url.PathEscape(parts[1])
+	if len(parts) == 3 {
+		secretURL += "/" + url.PathEscape(parts[2])
+	}
+	secretURL += "?api-version=" + KeyVaultAPIVersion
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get secret %s: %s: %s", ref, resp.Status, body)
+	}
+
+	var result struct {
+		Value *string `json:"value"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Value == nil {
+		return "", fmt.Errorf("no secret value in the response for %s", ref)
+	}
+	return *result.Value, nil
+}
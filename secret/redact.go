@@ -0,0 +1,157 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MinLength is the length below which a value is not redacted, so short
+// values such as "1" or "true" do not blank out unrelated output.
+const MinLength = 4
+
+// Mask replaces redacted values.
+const Mask = "***"
+
+// Redactor replaces known secrets in text.
+type Redactor struct {
+	mu       sync.RWMutex
+	secrets  map[string]bool
+	replacer *strings.Replacer
+}
+
+var defaultRedactor = &Redactor{}
+
+// DefaultRedactor returns the redactor shared by the process.
+func DefaultRedactor() *Redactor {
+	return defaultRedactor
+}
+
+// Add registers a secret. Its JSON-escaped forms, with and without HTML
+// characters escaped, are registered as well, so the secret is also caught
+// inside printed artifact definitions however they were encoded.
+func (r *Redactor) Add(secret string) {
+	if len(secret) < MinLength {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.secrets == nil {
+		r.secrets = make(map[string]bool)
+	}
+	for _, s := range append([]string{secret}, jsonEscaped(secret)...) {
+		r.secrets[s] = true
+	}
+
+	// Longer secrets first, so one containing another is masked whole
+	secrets := make([]string, 0, len(r.secrets))
+	for s := range r.secrets {
+		secrets = append(secrets, s)
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		if len(secrets[i]) != len(secrets[j]) {
+			return len(secrets[i]) > len(secrets[j])
+		}
+		return secrets[i] < secrets[j]
+	})
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, s := range secrets {
+		pairs = append(pairs, s, Mask)
+	}
+	r.replacer = strings.NewReplacer(pairs...)
+}
+
+// jsonEscaped returns s as it appears inside a JSON string, escaped by
+// json.Marshal and by an encoder that leaves <, > and & alone.
+func jsonEscaped(s string) []string {
+	var forms []string
+	for _, escapeHTML := range []bool{true, false} {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(escapeHTML)
+		enc.Encode(s)
+		quoted := strings.TrimSuffix(buf.String(), "\n")
+		forms = append(forms, quoted[1:len(quoted)-1])
+	}
+	return forms
+}
+
+// Redact returns s with every secret masked. A nil Redactor masks nothing.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.replacer == nil {
+		return s
+	}
+	return r.replacer.Replace(s)
+}
+
+// Capture points *f at a pipe whose output is redacted and written to the
+// original file. Output is passed on as soon as the writer pauses, so a
+// prompt shows before its answer is read, except for a tail that may be the
+// start of a secret finished by a later write. The returned function
+// restores *f and waits until everything written has been passed on.
+func (r *Redactor) Capture(f **os.File) (func(), error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	original := *f
+	*f = pw
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 32*1024)
+		var pending string
+		for {
+			n, err := pr.Read(buf)
+			pending += string(buf[:n])
+
+			end := len(pending)
+			if err == nil {
+				end -= r.partialSuffix(pending)
+			}
+			if end > 0 {
+				io.WriteString(original, r.Redact(pending[:end]))
+				pending = pending[end:]
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return func() {
+		*f = original
+		pw.Close()
+		<-done
+		pr.Close()
+	}, nil
+}
+
+// partialSuffix returns the length of the longest suffix of s that is the
+// start, but not the whole, of a secret.
+func (r *Redactor) partialSuffix(s string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	longest := 0
+	for secret := range r.secrets {
+		for n := min(len(secret)-1, len(s)); n > longest; n-- {
+			if strings.HasSuffix(s, secret[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
@@ -0,0 +1,128 @@
+// Package secret resolves ${scheme:reference} placeholders in parameter
+// values and keeps the resolved secrets out of the log.
+package secret
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Resolver resolves the reference of a placeholder to its value.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// ResolverFunc adapts a function to Resolver.
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve implements Resolver.
+func (f ResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// Env resolves ${env:NAME} from the process environment.
+var Env = ResolverFunc(func(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+})
+
+// File resolves ${file:path} to the content of the file, without a trailing
+// newline. Relative paths are relative to the working directory.
+var File = ResolverFunc(func(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %v", err)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"), nil
+})
+
+// Static resolves references from a map.
+type Static map[string]string
+
+// Resolve implements Resolver.
+func (s Static) Resolve(ctx context.Context, ref string) (string, error) {
+	value, ok := s[ref]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found", ref)
+	}
+	return value, nil
+}
+
+// LoadStatic loads a YAML file mapping references to values, for example
+// Key Vault secrets served locally in tests:
+//
+//	kv-prod/sql-password: local-password
+func LoadStatic(filePath string) (Static, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %v", err)
+	}
+
+	var s Static
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file %s: %v", filePath, err)
+	}
+	return s, nil
+}
+
+// Expander replaces ${scheme:reference} placeholders through the resolver
+// registered for the scheme. $${ stands for a literal ${. Every resolved
+// value is added to Redactor.
+type Expander struct {
+	Resolvers map[string]Resolver
+	Redactor  *Redactor
+}
+
+// Expand replaces the placeholders of s.
+func (e *Expander) Expand(ctx context.Context, s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated placeholder in %q", s[i:])
+		}
+		placeholder := s[i+2 : i+end]
+		scheme, ref, ok := strings.Cut(placeholder, ":")
+		if !ok || ref == "" {
+			return "", fmt.Errorf("placeholder ${%s} is not ${scheme:reference}", placeholder)
+		}
+		resolver, ok := e.Resolvers[scheme]
+		if !ok {
+			return "", fmt.Errorf("placeholder ${%s} has unknown scheme %q", placeholder, scheme)
+		}
+
+		value, err := resolver.Resolve(ctx, ref)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve ${%s}: %v", placeholder, err)
+		}
+		if e.Redactor != nil {
+			e.Redactor.Add(value)
+		}
+
+		b.WriteString(s[:i])
+		b.WriteString(value)
+		s = s[i+end+1:]
+	}
+}
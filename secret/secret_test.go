@@ -0,0 +1,217 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "password.txt")
+	if err := os.WriteFile(secretFile, []byte("file-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	secretsFile := filepath.Join(dir, "secrets.yaml")
+	if err := os.WriteFile(secretsFile, []byte("kv-prod/sql-password: vault-secret\nkv-prod/sql-password/v2: vault-secret-v2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keyVault, err := LoadStatic(secretsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SYNAPSECTL_TEST_USER", "env-user")
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr string
+		// redacted is a resolved value the redactor must mask afterwards.
+		redacted string
+	}{
+		{name: "no placeholder", in: "plain value", want: "plain value"},
+		{name: "env", in: "User=${env:SYNAPSECTL_TEST_USER};", want: "User=env-user;", redacted: "env-user"},
+		{name: "file", in: "${file:" + secretFile + "}", want: "file-secret", redacted: "file-secret"},
+		{name: "keyvault", in: "Password=${keyvault:kv-prod/sql-password}", want: "Password=vault-secret", redacted: "vault-secret"},
+		{name: "keyvault version", in: "${keyvault:kv-prod/sql-password/v2}", want: "vault-secret-v2", redacted: "vault-secret-v2"},
+		{name: "several", in: "${env:SYNAPSECTL_TEST_USER}:${keyvault:kv-prod/sql-password}", want: "env-user:vault-secret"},
+		{name: "escaped", in: "$${env:SYNAPSECTL_TEST_USER}", want: "${env:SYNAPSECTL_TEST_USER}"},
+		{name: "missing env", in: "${env:SYNAPSECTL_TEST_UNSET}", wantErr: "environment variable SYNAPSECTL_TEST_UNSET is not set"},
+		{name: "missing file", in: "${file:" + filepath.Join(dir, "absent") + "}", wantErr: "failed to read secret file"},
+		{name: "missing secret", in: "${keyvault:kv-prod/absent}", wantErr: "secret kv-prod/absent not found"},
+		{name: "unknown scheme", in: "${vault:x}", wantErr: `unknown scheme "vault"`},
+		{name: "no reference", in: "${env:}", wantErr: "is not ${scheme:reference}"},
+		{name: "unterminated", in: "${env:SYNAPSECTL_TEST_USER", wantErr: "unterminated placeholder"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Expander{
+				Resolvers: map[string]Resolver{"env": Env, "file": File, "keyvault": keyVault},
+				Redactor:  &Redactor{},
+			}
+			got, err := e.Expand(context.Background(), tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expand(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expand(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expand(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if tt.redacted != "" && e.Redactor.Redact(tt.redacted) != Mask {
+				t.Errorf("resolved value %q was not registered with the redactor", tt.redacted)
+			}
+		})
+	}
+}
+
+func TestKeyVaultReference(t *testing.T) {
+	for _, ref := range []string{"vault", "vault/", "/secret", "vault/secret/version/extra"} {
+		_, err := (&KeyVault{}).Resolve(context.Background(), ref)
+		if err == nil || !strings.Contains(err.Error(), "is not vault/secret[/version]") {
+			t.Errorf("Resolve(%q) error = %v, want a reference error", ref, err)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	r := &Redactor{}
+	r.Add("hunter22")
+	r.Add(`pa"ss\word`)
+	r.Add("p&ss<w>rd")
+	r.Add("abc")
+
+	tests := []struct {
+		in, want string
+	}{
+		{"password is hunter22", "password is ***"},
+		{"hunter22hunter22", "******"},
+		{`"value": "pa\"ss\\word"`, `"value": "***"`},
+		{`pa"ss\word`, "***"},
+		{`{"password":"p\u0026ss\u003cw\u003erd"}`, `{"password":"***"}`},
+		{`{"password":"p&ss<w>rd"}`, `{"password":"***"}`},
+		// Part of a secret is not recognised, so output must be masked
+		// before it is shortened
+		{"cut at p&ss<w", "cut at p&ss<w"},
+		{"abc is too short to redact", "abc is too short to redact"},
+	}
+	for _, tt := range tests {
+		if got := r.Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	var none *Redactor
+	if got := none.Redact("hunter22"); got != "hunter22" {
+		t.Errorf("nil Redactor masked %q", got)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{
+			name:   "lines",
+			writes: []string{"user admin\n", "password hunter22\n"},
+			want:   "user admin\npassword ***\n",
+		},
+		{
+			name:   "secret split across writes",
+			writes: []string{"password hun", "ter", "22 done\n"},
+			want:   "password *** done\n",
+		},
+		{
+			name:   "secret prefix never completed",
+			writes: []string{"hunt", "ing\n"},
+			want:   "hunting\n",
+		},
+		{
+			name:   "secret across a full read",
+			writes: []string{strings.Repeat("x", 32*1024-3) + "hunter22\n"},
+			want:   strings.Repeat("x", 32*1024-3) + "***\n",
+		},
+		{
+			name:   "prompt without newline",
+			writes: []string{"Apply these changes? [y/N] "},
+			want:   "Apply these changes? [y/N] ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Redactor{}
+			r.Add("hunter22")
+
+			out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer out.Close()
+
+			f := out
+			restore, err := r.Capture(&f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range tt.writes {
+				if _, err := f.WriteString(w); err != nil {
+					t.Fatal(err)
+				}
+				// Let the reader pass on what it has before the next write
+				time.Sleep(10 * time.Millisecond)
+			}
+			restore()
+
+			got, err := os.ReadFile(out.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("captured %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapturePassesPromptOn(t *testing.T) {
+	r := &Redactor{}
+	r.Add("hunter22")
+
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	f := out
+	restore, err := r.Capture(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restore()
+
+	f.WriteString("Apply these changes? [y/N] ")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, _ := os.ReadFile(out.Name())
+		if string(got) == "Apply these changes? [y/N] " {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("prompt not passed on before the writer finished, got %q", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,197 @@
+// Package signature signs and verifies artifact packages with ed25519 or
+// ECDSA keys kept in PEM files. The signed payload is the digest of the
+// package manifest, which in turn records the SHA-256 of every artifact.
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// MediaType is the media type of a signature document.
+const MediaType = "application/vnd.synapse.signature.v1+json"
+
+// Extension is appended to the path of a zip or tar.gz package to name its
+// detached signature.
+const Extension = ".sig"
+
+// Algorithms of a signature.
+const (
+	Ed25519     = "ed25519"
+	ECDSASHA256 = "ecdsa-sha256"
+)
+
+// Signature is a signed package manifest digest.
+type Signature struct {
+	// Digest is the signed digest, sha256:<hex> of the package manifest.
+	Digest    string `json:"digest"`
+	Algorithm string `json:"algorithm"`
+	// KeyID is the SHA-256 of the signing public key in PKIX form.
+	KeyID string `json:"keyId"`
+	Value []byte `json:"signature"`
+}
+
+// ManifestDigest returns the digest a signature signs for the given
+// manifest.json content.
+func ManifestDigest(manifest []byte) string {
+	sum := sha256.Sum256(manifest)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// LoadPrivateKey reads an ed25519 or ECDSA private key from a PEM file in
+// PKCS #8 or SEC 1 ("EC PRIVATE KEY") form.
+func LoadPrivateKey(filePath string) (crypto.Signer, error) {
+	block, err := readPEM(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s holds a %s, not a private key", filePath, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %v", filePath, err)
+	}
+
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported key type %T, use ed25519 or ECDSA", filePath, key)
+	}
+}
+
+// LoadPublicKey reads an ed25519 or ECDSA public key from a PEM file in PKIX
+// ("PUBLIC KEY") form. A private key file yields its public key.
+func LoadPublicKey(filePath string) (crypto.PublicKey, error) {
+	block, err := readPEM(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type != "PUBLIC KEY" {
+		key, err := LoadPrivateKey(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return key.Public(), nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %v", filePath, err)
+	}
+	switch key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported key type %T, use ed25519 or ECDSA", filePath, key)
+	}
+}
+
+func readPEM(filePath string) (*pem.Block, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", filePath)
+	}
+	return block, nil
+}
+
+// KeyID returns the SHA-256 of the PKIX form of a public key.
+func KeyID(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %v", err)
+	}
+	sum := sha256.Sum256(der)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// Sign signs digest. Ed25519 signs the digest string itself; ECDSA signs its
+// SHA-256 and encodes the signature in ASN.1.
+func Sign(key crypto.Signer, digest string) (*Signature, error) {
+	keyID, err := KeyID(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	sig := &Signature{Digest: digest, KeyID: keyID}
+	switch key.(type) {
+	case ed25519.PrivateKey:
+		sig.Algorithm = Ed25519
+		sig.Value, err = key.Sign(rand.Reader, []byte(digest), crypto.Hash(0))
+	case *ecdsa.PrivateKey:
+		sig.Algorithm = ECDSASHA256
+		sum := sha256.Sum256([]byte(digest))
+		sig.Value, err = key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use ed25519 or ECDSA", key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign %s: %v", digest, err)
+	}
+	return sig, nil
+}
+
+// Verify checks that s signs digest with key.
+func (s *Signature) Verify(key crypto.PublicKey, digest string) error {
+	if s.Digest != digest {
+		return fmt.Errorf("signature is for %s, not %s", s.Digest, digest)
+	}
+
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		if s.Algorithm != Ed25519 || !ed25519.Verify(key, []byte(digest), s.Value) {
+			return fmt.Errorf("signature of %s does not match the key", digest)
+		}
+	case *ecdsa.PublicKey:
+		sum := sha256.Sum256([]byte(digest))
+		if s.Algorithm != ECDSASHA256 || !ecdsa.VerifyASN1(key, sum[:], s.Value) {
+			return fmt.Errorf("signature of %s does not match the key", digest)
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+	return nil
+}
+
+// Marshal encodes the signature document.
+func (s *Signature) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signature: %v", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Parse decodes a signature document.
+func Parse(data []byte) (*Signature, error) {
+	var s Signature
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse signature: %v", err)
+	}
+	if s.Digest == "" || len(s.Value) == 0 {
+		return nil, fmt.Errorf("signature has no digest or value")
+	}
+	return &s, nil
+}
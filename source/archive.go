@@ -0,0 +1,145 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/utsavudhungana/artifactsrepo/archive"
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/image"
+	"github.com/utsavudhungana/artifactsrepo/signature"
+)
+
+// Zip reads artifacts from a local zip package.
+type Zip struct {
+	Path string
+
+	verifier
+}
+
+// Artifacts implements ArtifactSource.
+func (z *Zip) Artifacts(ctx context.Context) ([]artifact.Artifact, error) {
+	data, err := archive.ReadFile(z.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := archive.Unzip(data)
+	if err != nil {
+		return nil, err
+	}
+	return z.artifacts(files, z.Path)
+}
+
+// IsPackage implements Packaged.
+func (z *Zip) IsPackage() bool {
+	return true
+}
+
+// Signatures implements Signed with the detached signature next to the
+// package, if there is one.
+func (z *Zip) Signatures(ctx context.Context) ([][]byte, error) {
+	return detachedSignatures(z.Path)
+}
+
+func (z *Zip) String() string {
+	return z.Path
+}
+
+// TarGz reads artifacts from a local tar.gz package.
+type TarGz struct {
+	Path string
+
+	verifier
+}
+
+// Artifacts implements ArtifactSource.
+func (t *TarGz) Artifacts(ctx context.Context) ([]artifact.Artifact, error) {
+	data, err := archive.ReadFile(t.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := archive.UntarGz(data)
+	if err != nil {
+		return nil, err
+	}
+	return t.artifacts(files, t.Path)
+}
+
+// IsPackage implements Packaged.
+func (t *TarGz) IsPackage() bool {
+	return true
+}
+
+// Signatures implements Signed with the detached signature next to the
+// package, if there is one.
+func (t *TarGz) Signatures(ctx context.Context) ([][]byte, error) {
+	return detachedSignatures(t.Path)
+}
+
+func (t *TarGz) String() string {
+	return t.Path
+}
+
+// Tar reads artifacts from a local uncompressed tar: a docker save archive
+// of an image built by push_dir.sh, whose files below /data are read, or a
+// plain tar of package files.
+type Tar struct {
+	Path string
+
+	image bool
+	verifier
+}
+
+// Artifacts implements ArtifactSource.
+func (t *Tar) Artifacts(ctx context.Context) ([]artifact.Artifact, error) {
+	data, err := archive.ReadFile(t.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := archive.Untar(data)
+	if err != nil {
+		return nil, err
+	}
+	t.image = image.IsDockerArchive(files)
+	if t.image {
+		files, err = image.FromDockerArchive(files, image.DefaultRoot)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", t.Path, err)
+		}
+	}
+	return t.artifacts(files, t.Path)
+}
+
+// IsPackage implements Packaged: a plain tar of package files is one, a
+// docker save archive is not.
+func (t *Tar) IsPackage() bool {
+	return !t.image
+}
+
+// Signatures implements Signed with the detached signature next to the
+// package, if there is one.
+func (t *Tar) Signatures(ctx context.Context) ([][]byte, error) {
+	return detachedSignatures(t.Path)
+}
+
+func (t *Tar) String() string {
+	return t.Path
+}
+
+// detachedSignatures reads the signature file next to a package.
+func detachedSignatures(path string) ([][]byte, error) {
+	data, err := os.ReadFile(path + signature.Extension)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %v", err)
+	}
+	return [][]byte{data}, nil
+}
@@ -0,0 +1,47 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/utsavudhungana/artifactsrepo/archive"
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+)
+
+// Directory reads artifacts from a checkout in the Synapse Git layout, such
+// as notebook/Notebook1.json below Root.
+type Directory struct {
+	Root string
+
+	verifier
+}
+
+// Artifacts implements ArtifactSource.
+func (d *Directory) Artifacts(ctx context.Context) ([]artifact.Artifact, error) {
+	filePaths, err := archive.JSONFilesInDirectory(d.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, filePath := range filePaths {
+		rel, err := filepath.Rel(d.Root, filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %v", filePath, err)
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %v", filePath, err)
+		}
+		files[filepath.ToSlash(rel)] = content
+	}
+
+	return d.artifacts(files, d.Root)
+}
+
+func (d *Directory) String() string {
+	return d.Root
+}
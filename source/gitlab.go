@@ -0,0 +1,60 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/gitlab"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+)
+
+// GitLab reads artifacts from a GitLab repository at a fixed ref.
+type GitLab struct {
+	Client *gitlab.Client
+
+	verifier
+}
+
+// Artifacts implements ArtifactSource. Only files laid out as
+// <type>/<name>.json below the client's root, and a manifest.json next to
+// them, are downloaded; other JSON files are reported by Skipped.
+func (g *GitLab) Artifacts(ctx context.Context) ([]artifact.Artifact, error) {
+	filePaths, err := g.Client.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	var skipped []string
+	for _, filePath := range filePaths {
+		if !artifact.InLayout(filePath) && filePath != manifest.FileName {
+			if artifact.IsJSON(filePath) {
+				skipped = append(skipped, filePath)
+			}
+			continue
+		}
+
+		content, err := g.Client.GetFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve %s: %v", filePath, err)
+		}
+		files[filePath] = content
+	}
+
+	artifacts, err := g.artifacts(files, g.String())
+	if err != nil {
+		return nil, err
+	}
+	g.skipped = append(g.skipped, skipped...)
+	sort.Strings(g.skipped)
+	if len(artifacts) == 0 {
+		return nil, fmt.Errorf("%s holds no artifacts in the Synapse Git layout", g)
+	}
+	return artifacts, nil
+}
+
+func (g *GitLab) String() string {
+	return g.Client.String()
+}
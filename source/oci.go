@@ -0,0 +1,69 @@
+package source
+
+import (
+	"context"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/image"
+	"github.com/utsavudhungana/artifactsrepo/registry"
+)
+
+// OCI reads artifacts from a package stored in an OCI registry, in either
+// registry layout.
+type OCI struct {
+	Reference   string
+	Credentials registry.CredentialProvider
+
+	target oras.ReadOnlyTarget
+	desc   ocispec.Descriptor
+	image  bool
+
+	verifier
+}
+
+// Artifacts implements ArtifactSource.
+func (o *OCI) Artifacts(ctx context.Context) ([]artifact.Artifact, error) {
+	target, ref, err := registry.Open(o.Reference, o.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, files, err := registry.ResolvePackage(ctx, target, ref, nil)
+	if err != nil {
+		return nil, err
+	}
+	_, m, err := registry.FetchManifest(ctx, target, desc.Digest.String())
+	if err != nil {
+		return nil, err
+	}
+	o.target, o.desc, o.image = target, desc, image.IsConfig(m.Config.MediaType)
+	return o.artifacts(files, o.Reference)
+}
+
+// IsPackage implements Packaged: a package pushed by synapsectl is one, a
+// container image is not.
+func (o *OCI) IsPackage() bool {
+	return !o.image
+}
+
+// Signatures implements Signed with the signatures attached to the package
+// manifest that was read.
+func (o *OCI) Signatures(ctx context.Context) ([][]byte, error) {
+	if o.target == nil {
+		return nil, fmt.Errorf("%s has not been read", o.Reference)
+	}
+	return registry.Signatures(ctx, o.target, o.desc)
+}
+
+// Descriptor returns the descriptor of the package manifest that was read.
+func (o *OCI) Descriptor() ocispec.Descriptor {
+	return o.desc
+}
+
+func (o *OCI) String() string {
+	return o.Reference
+}
@@ -0,0 +1,143 @@
+// Package source loads Synapse artifacts from packages, directories, GitLab
+// repositories and OCI registries behind a single interface, so any source
+// can feed any deploy target.
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/utsavudhungana/artifactsrepo/archive"
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+	"github.com/utsavudhungana/artifactsrepo/signature"
+)
+
+// ArtifactSource yields the artifacts of a package.
+type ArtifactSource interface {
+	// Artifacts loads every artifact, sorted with artifact.Sort.
+	Artifacts(ctx context.Context) ([]artifact.Artifact, error)
+	// String describes the source for log lines.
+	String() string
+}
+
+// Verified is implemented by sources that check the manifest of a package.
+// After Artifacts succeeds, Manifest returns the verified manifest, or nil
+// when the package has none, and ManifestDigest the digest a package
+// signature signs.
+type Verified interface {
+	Manifest() *manifest.Manifest
+	ManifestDigest() string
+}
+
+// Signed is implemented by sources that can carry package signatures. After
+// Artifacts succeeds, Signatures returns the signature documents of the
+// package that was read.
+type Signed interface {
+	Signatures(ctx context.Context) ([][]byte, error)
+}
+
+// Packaged is implemented by sources that can read packages built by
+// synapsectl package or push, which carry a manifest. After Artifacts
+// succeeds, IsPackage reports whether the source read such a package,
+// rather than files that never have a manifest, such as a push_dir.sh
+// image.
+type Packaged interface {
+	IsPackage() bool
+}
+
+// Skipping is implemented by sources that leave out JSON files outside the
+// Synapse Git layout. After Artifacts succeeds, Skipped returns their paths
+// relative to the package root; the package manifest is not among them.
+type Skipping interface {
+	Skipped() []string
+}
+
+// verifier builds artifacts from package files and verifies them against
+// the package manifest, if there is one.
+type verifier struct {
+	manifest *manifest.Manifest
+	digest   string
+	skipped  []string
+}
+
+// Manifest implements Verified.
+func (v *verifier) Manifest() *manifest.Manifest {
+	return v.manifest
+}
+
+// ManifestDigest implements Verified.
+func (v *verifier) ManifestDigest() string {
+	return v.digest
+}
+
+// Skipped implements Skipping.
+func (v *verifier) Skipped() []string {
+	return v.skipped
+}
+
+func (v *verifier) artifacts(files map[string][]byte, origin string) ([]artifact.Artifact, error) {
+	artifacts, skipped, err := artifact.FromFiles(files, origin)
+	if err != nil {
+		return nil, err
+	}
+	v.skipped = nil
+	for _, rel := range skipped {
+		if rel != manifest.FileName {
+			v.skipped = append(v.skipped, rel)
+		}
+	}
+
+	data, ok := files[manifest.FileName]
+	if !ok {
+		return artifacts, nil
+	}
+	m, err := manifest.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", origin, err)
+	}
+	if err := m.Verify(artifacts); err != nil {
+		return nil, fmt.Errorf("%s: %v", origin, err)
+	}
+	v.manifest = m
+	v.digest = signature.ManifestDigest(data)
+	return artifacts, nil
+}
+
+// Files is a source over package files that are already in memory.
+type Files struct {
+	Files  map[string][]byte
+	Origin string
+
+	verifier
+}
+
+// Artifacts implements ArtifactSource.
+func (f *Files) Artifacts(ctx context.Context) ([]artifact.Artifact, error) {
+	return f.artifacts(f.Files, f.Origin)
+}
+
+func (f *Files) String() string {
+	return f.Origin
+}
+
+// Open returns the source for a local path: a directory, a .tar.gz/.tgz
+// archive, a .tar archive (such as docker save output) or a zip archive.
+func Open(path string) (ArtifactSource, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+
+	switch {
+	case info.IsDir():
+		return &Directory{Root: path}, nil
+	case archive.IsTarGz(path):
+		return &TarGz{Path: path}, nil
+	case archive.IsTar(path):
+		return &Tar{Path: path}, nil
+	default:
+		return &Zip{Path: path}, nil
+	}
+}
@@ -0,0 +1,151 @@
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+)
+
+func keys(artifacts []artifact.Artifact) []string {
+	var keys []string
+	for _, a := range artifacts {
+		keys = append(keys, a.Key())
+	}
+	return keys
+}
+
+func testManifest(t *testing.T, rel, content string) []byte {
+	t.Helper()
+	a, err := artifact.New(rel, []byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := manifest.New([]artifact.Artifact{a}, manifest.Source{}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for rel, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDirectorySkipped(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"notebook/nb1.json":            `{"name":"nb1"}`,
+		"widget/w1.json":               `{"name":"w1"}`,
+		"publish_config.json":          `{}`,
+		"synapse/notebook/nb2.json":    `{"name":"nb2"}`,
+		"notebook/readme.md":           "not an artifact",
+		"sql-script/script1.json":      `{"name":"script1"}`,
+		"linked-service/storage.json":  `{"name":"storage"}`,
+		"spark-job-definition/j1.json": `{"name":"j1"}`,
+	})
+
+	d := &Directory{Root: root}
+	artifacts, err := d.Artifacts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantKeys := []string{"linkedService/storage", "notebook/nb1", "sqlscript/script1", "sparkJobDefinition/j1"}
+	if got := keys(artifacts); !reflect.DeepEqual(got, wantKeys) {
+		t.Errorf("artifacts = %v, want %v", got, wantKeys)
+	}
+	wantSkipped := []string{"publish_config.json", "synapse/notebook/nb2.json", "widget/w1.json"}
+	if got := d.Skipped(); !reflect.DeepEqual(got, wantSkipped) {
+		t.Errorf("Skipped() = %v, want %v", got, wantSkipped)
+	}
+}
+
+func TestFilesSkipped(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string][]byte
+		wantKeys    []string
+		wantSkipped []string
+		wantErr     string
+	}{
+		{
+			name: "unknown folder",
+			files: map[string][]byte{
+				"notebook/nb1.json":    []byte(`{"name":"nb1"}`),
+				"managed-pe/pe1.json":  []byte(`{"name":"pe1"}`),
+				"managed-pe/notes.txt": []byte("ignored"),
+			},
+			wantKeys:    []string{"notebook/nb1"},
+			wantSkipped: []string{"managed-pe/pe1.json"},
+		},
+		{
+			name: "manifest is not skipped",
+			files: map[string][]byte{
+				"notebook/nb1.json": []byte(`{"name":"nb1"}`),
+				manifest.FileName:   testManifest(t, "notebook/nb1.json", `{"name":"nb1"}`),
+				"other/extra.json":  []byte(`{}`),
+			},
+			wantKeys:    []string{"notebook/nb1"},
+			wantSkipped: []string{"other/extra.json"},
+		},
+		{
+			name: "az CLI folder next to the Studio folder",
+			files: map[string][]byte{
+				"linkedService/storage.json":  []byte(`{"name":"storage"}`),
+				"linked-service/storage.json": []byte(`{"name":"storage"}`),
+			},
+			wantErr: "linked-service/storage.json and linkedService/storage.json both define linkedService/storage",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Files{Files: tt.files, Origin: "test.zip"}
+			artifacts, err := f.Artifacts(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Artifacts error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keys(artifacts); !reflect.DeepEqual(got, tt.wantKeys) {
+				t.Errorf("artifacts = %v, want %v", got, tt.wantKeys)
+			}
+			if got := f.Skipped(); !reflect.DeepEqual(got, tt.wantSkipped) {
+				t.Errorf("Skipped() = %v, want %v", got, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+// TestDeployExample loads the az CLI export kept in artifact_deploy, whose
+// linked-service/ and sql-script/ folders used to be dropped silently.
+func TestDeployExample(t *testing.T) {
+	d := &Directory{Root: filepath.Join("..", "artifact_deploy", "artifacts")}
+	artifacts, err := d.Artifacts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 7 || len(d.Skipped()) != 0 {
+		t.Errorf("loaded %v, skipped %v; want all 7 files loaded", keys(artifacts), d.Skipped())
+	}
+}
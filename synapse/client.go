@@ -0,0 +1,246 @@
+// Package synapse talks to the Synapse workspace data-plane REST API.
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/httpclient"
+)
+
+// APIVersion is the data-plane API version used for artifact calls.
+const APIVersion = "2020-12-01"
+
+// collections maps artifact types to their REST collection and API version.
+var collections = map[string]struct {
+	path       string
+	apiVersion string
+}{
+	artifact.ManagedVirtualNetwork: {"managedVirtualNetworks", APIVersion},
+	artifact.IntegrationRuntime:    {"integrationRuntimes", APIVersion},
+	artifact.LinkedService:         {"linkedServices", APIVersion},
+	artifact.Dataset:               {"datasets", APIVersion},
+	artifact.DataFlow:              {"dataflows", APIVersion},
+	artifact.Notebook:              {"notebooks", APIVersion},
+	artifact.SQLScript:             {"sqlScripts", APIVersion},
+	artifact.KQLScript:             {"kqlScripts", "2021-06-01-preview"},
+	artifact.SparkConfiguration:    {"sparkconfigurations", "2021-06-01-preview"},
+	artifact.SparkJobDefinition:    {"sparkJobDefinitions", APIVersion},
+	artifact.Pipeline:              {"pipelines", APIVersion},
+	artifact.Trigger:               {"triggers", APIVersion},
+}
+
+// Serves reports whether the data plane serves artifacts of a type.
+// Credentials, for one, only exist in Azure Resource Manager.
+func Serves(artifactType string) bool {
+	_, ok := collections[artifactType]
+	return ok
+}
+
+// Client calls the data-plane API of a single Synapse workspace.
+type Client struct {
+	// Endpoint is the workspace development endpoint, for example
+	// https://myworkspace.dev.azuresynapse.net.
+	Endpoint string
+	// Token is sent as bearer token. It may be empty when HTTPClient
+	// authenticates requests itself.
+	Token      string
+	HTTPClient *http.Client
+	// PollInterval is the first delay between polls of a long-running
+	// operation when Synapse does not send Retry-After. It doubles after
+	// each poll up to MaxPollInterval.
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+	// PollTimeout bounds how long a single operation is polled.
+	PollTimeout time.Duration
+}
+
+// NewClient creates a client for the named workspace.
+func NewClient(workspaceName, token string) *Client {
+	return &Client{
+		Endpoint:        Endpoint(workspaceName),
+		Token:           token,
+		HTTPClient:      httpclient.Default(),
+		PollInterval:    time.Second,
+		MaxPollInterval: 30 * time.Second,
+		PollTimeout:     10 * time.Minute,
+	}
+}
+
+// Endpoint returns the development endpoint of the named workspace in the
+// public cloud.
+func Endpoint(workspaceName string) string {
+	return PublicCloud.Endpoint(workspaceName)
+}
+
+// ResponseError is returned when Synapse answers with an unexpected status.
+type ResponseError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s %s returned %d: %s", e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// IsNotFound reports whether err is a 404 response.
+func IsNotFound(err error) bool {
+	var respErr *ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
+
+// ArtifactURL builds the URL of an artifact collection below endpoint, or of
+// a single artifact when name is not empty.
+func ArtifactURL(endpoint, artifactType, name string) (string, error) {
+	collection, ok := collections[artifactType]
+	if !ok {
+		return "", fmt.Errorf("unsupported artifact type: %s", artifactType)
+	}
+
+	u := fmt.Sprintf("%s/%s", endpoint, collection.path)
+	if name != "" {
+		u += "/" + url.PathEscape(name)
+	}
+	return u + "?api-version=" + collection.apiVersion, nil
+}
+
+// PutArtifact creates or updates an artifact. When Synapse accepts the
+// request asynchronously, PutArtifact waits for the operation to finish and
+// returns its outcome.
+func (c *Client) PutArtifact(ctx context.Context, artifactType, name string, content []byte) error {
+	if artifact.IsReadOnly(artifactType) {
+		return fmt.Errorf("%s artifacts cannot be written through the data plane", artifactType)
+	}
+	apiURL, err := ArtifactURL(c.Endpoint, artifactType, name)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.send(ctx, http.MethodPut, apiURL, content)
+	if err != nil {
+		return err
+	}
+	return c.wait(ctx, resp)
+}
+
+// GetArtifact fetches the definition of a single artifact.
+func (c *Client) GetArtifact(ctx context.Context, artifactType, name string) ([]byte, error) {
+	apiURL, err := ArtifactURL(c.Endpoint, artifactType, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.do(ctx, http.MethodGet, apiURL, nil)
+}
+
+// DeleteArtifact deletes an artifact, waiting for an asynchronous delete to
+// finish.
+func (c *Client) DeleteArtifact(ctx context.Context, artifactType, name string) error {
+	if artifact.IsReadOnly(artifactType) {
+		return fmt.Errorf("%s artifacts cannot be deleted through the data plane", artifactType)
+	}
+	apiURL, err := ArtifactURL(c.Endpoint, artifactType, name)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.send(ctx, http.MethodDelete, apiURL, nil)
+	if err != nil {
+		return err
+	}
+	return c.wait(ctx, resp)
+}
+
+// ListArtifacts returns the definitions of every artifact of a type,
+// following nextLink until the last page.
+func (c *Client) ListArtifacts(ctx context.Context, artifactType string) ([]json.RawMessage, error) {
+	apiURL, err := ArtifactURL(c.Endpoint, artifactType, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var items []json.RawMessage
+	for apiURL != "" {
+		body, err := c.do(ctx, http.MethodGet, apiURL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Value    []json.RawMessage `json:"value"`
+			NextLink string            `json:"nextLink"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse %s list: %v", artifactType, err)
+		}
+		items = append(items, page.Value...)
+		apiURL = page.NextLink
+	}
+	return items, nil
+}
+
+// response is a successful data-plane response.
+type response struct {
+	method     string
+	url        string
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+func (c *Client) do(ctx context.Context, method, apiURL string, content []byte) ([]byte, error) {
+	resp, err := c.send(ctx, method, apiURL, content)
+	if err != nil {
+		return nil, err
+	}
+	return resp.body, nil
+}
+
+// send issues a request and fails with a *ResponseError unless Synapse
+// answers with a success status.
+func (c *Client) send(ctx context.Context, method, apiURL string, content []byte) (*response, error) {
+	var body io.Reader
+	if content != nil {
+		body = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %v", method, err)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if content != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %v", method, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent:
+	default:
+		return nil, &ResponseError{Method: method, URL: apiURL, StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	return &response{method: method, url: apiURL, statusCode: resp.StatusCode, header: resp.Header, body: respBody}, nil
+}
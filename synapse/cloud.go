@@ -0,0 +1,100 @@
+package synapse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+)
+
+// Cloud holds the endpoints of an Azure cloud.
+type Cloud struct {
+	Name string
+	// Authority is the Azure AD login host.
+	Authority string
+	// Suffix is the DNS suffix of workspace development endpoints.
+	Suffix string
+	// KeyVaultSuffix is the DNS suffix of Key Vault vaults.
+	KeyVaultSuffix string
+	// ResourceManager is the Azure Resource Manager host.
+	ResourceManager string
+}
+
+var (
+	PublicCloud = Cloud{
+		Name:            "public",
+		Authority:       "https://login.microsoftonline.com/",
+		Suffix:          "dev.azuresynapse.net",
+		KeyVaultSuffix:  "vault.azure.net",
+		ResourceManager: "management.azure.com",
+	}
+	ChinaCloud = Cloud{
+		Name:            "china",
+		Authority:       "https://login.chinacloudapi.cn/",
+		Suffix:          "dev.azuresynapse.azure.cn",
+		KeyVaultSuffix:  "vault.azure.cn",
+		ResourceManager: "management.chinacloudapi.cn",
+	}
+	USGovernmentCloud = Cloud{
+		Name:            "usgovernment",
+		Authority:       "https://login.microsoftonline.us/",
+		Suffix:          "dev.azuresynapse.usgovcloudapi.net",
+		KeyVaultSuffix:  "vault.usgovcloudapi.net",
+		ResourceManager: "management.usgovcloudapi.net",
+	}
+)
+
+// CloudByName returns the named cloud: public, china or usgovernment, or
+// the az CLI names AzureCloud, AzureChinaCloud and AzureUSGovernment. An
+// empty name is the public cloud.
+func CloudByName(name string) (Cloud, error) {
+	switch strings.ToLower(name) {
+	case "", "public", "azurecloud":
+		return PublicCloud, nil
+	case "china", "azurechinacloud":
+		return ChinaCloud, nil
+	case "usgovernment", "azureusgovernment":
+		return USGovernmentCloud, nil
+	default:
+		return Cloud{}, fmt.Errorf("unknown cloud %q", name)
+	}
+}
+
+// orPublic returns the public cloud for the zero Cloud.
+func (c Cloud) orPublic() Cloud {
+	if c.Suffix == "" {
+		return PublicCloud
+	}
+	return c
+}
+
+// Endpoint returns the development endpoint of the named workspace.
+func (c Cloud) Endpoint(workspaceName string) string {
+	return fmt.Sprintf("https://%s.%s", workspaceName, c.orPublic().Suffix)
+}
+
+// Scope returns the OAuth 2.0 scope of the Synapse data plane.
+func (c Cloud) Scope() string {
+	return "https://" + c.orPublic().Suffix + "/.default"
+}
+
+// VaultURL returns the URL of the named Key Vault.
+func (c Cloud) VaultURL(vault string) string {
+	return fmt.Sprintf("https://%s.%s", vault, c.orPublic().KeyVaultSuffix)
+}
+
+// VaultScope returns the OAuth 2.0 scope of Key Vault.
+func (c Cloud) VaultScope() string {
+	return "https://" + c.orPublic().KeyVaultSuffix + "/.default"
+}
+
+// ResourceManagerScope returns the OAuth 2.0 scope of Azure Resource
+// Manager, which Azure Container Registry accepts for token exchange.
+func (c Cloud) ResourceManagerScope() string {
+	return "https://" + c.orPublic().ResourceManager + "/.default"
+}
+
+// configuration returns the Azure SDK configuration of the cloud.
+func (c Cloud) configuration() cloud.Configuration {
+	return cloud.Configuration{ActiveDirectoryAuthorityHost: c.orPublic().Authority}
+}
@@ -0,0 +1,136 @@
+package synapse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// operationStatus is the body of an Azure-AsyncOperation status monitor, and
+// of Location results that report a status.
+type operationStatus struct {
+	Status string `json:"status"`
+	Error  *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// OperationError is returned when a long-running operation ends in a
+// Failed or Canceled state.
+type OperationError struct {
+	URL     string
+	Status  string
+	Code    string
+	Message string
+}
+
+func (e *OperationError) Error() string {
+	if e.Code == "" && e.Message == "" {
+		return fmt.Sprintf("operation %s: %s", e.URL, e.Status)
+	}
+	return fmt.Sprintf("operation %s: %s: %s: %s", e.URL, e.Status, e.Code, e.Message)
+}
+
+// wait follows a 202 Accepted response until the operation behind it
+// finishes. Azure-AsyncOperation is preferred over Location, as in the Azure
+// REST guidelines; other responses are already final. A 202 without either
+// header is an error: the operation may still fail, and nothing tells when.
+func (c *Client) wait(ctx context.Context, resp *response) error {
+	if resp.statusCode != http.StatusAccepted {
+		return nil
+	}
+
+	monitor := resp.header.Get("Azure-AsyncOperation")
+	useStatus := monitor != ""
+	if !useStatus {
+		monitor = resp.header.Get("Location")
+	}
+	if monitor == "" {
+		return fmt.Errorf("%s %s was accepted without a Location or Azure-AsyncOperation header, so its outcome is unknown", resp.method, resp.url)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.PollTimeout)
+	defer cancel()
+
+	interval := c.PollInterval
+	delay := retryAfter(resp.header, interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for operation %s: %v", monitor, ctx.Err())
+		case <-time.After(delay):
+		}
+
+		poll, err := c.send(ctx, http.MethodGet, monitor, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("gave up waiting for operation %s: %v", monitor, ctx.Err())
+			}
+			return err
+		}
+
+		done, err := operationDone(monitor, poll, useStatus)
+		if done || err != nil {
+			return err
+		}
+
+		interval = backoff(interval, c.MaxPollInterval)
+		delay = retryAfter(poll.header, interval)
+	}
+}
+
+// backoff doubles interval, up to limit.
+func backoff(interval, limit time.Duration) time.Duration {
+	interval *= 2
+	if interval > limit {
+		return limit
+	}
+	return interval
+}
+
+// operationDone interprets a poll response. A status monitor is done once it
+// reports a terminal status; a Location monitor is done once it stops
+// answering 202, unless the final body reports a failed status.
+func operationDone(monitor string, poll *response, useStatus bool) (bool, error) {
+	var status operationStatus
+	if len(poll.body) > 0 {
+		if err := json.Unmarshal(poll.body, &status); err != nil && useStatus {
+			return false, fmt.Errorf("failed to parse operation status: %v", err)
+		}
+	}
+
+	if !useStatus && poll.statusCode == http.StatusAccepted {
+		return false, nil
+	}
+
+	switch strings.ToLower(status.Status) {
+	case "succeeded":
+		return true, nil
+	case "failed", "canceled", "cancelled":
+		opErr := &OperationError{URL: monitor, Status: status.Status}
+		if status.Error != nil {
+			opErr.Code = status.Error.Code
+			opErr.Message = status.Error.Message
+		}
+		return true, opErr
+	case "":
+		// Location results carry the resource itself once finished
+		return !useStatus, nil
+	default:
+		return false, nil
+	}
+}
+
+// retryAfter returns the delay requested by a Retry-After header given in
+// seconds, or fallback.
+func retryAfter(header http.Header, fallback time.Duration) time.Duration {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return fallback
+}
@@ -0,0 +1,235 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+)
+
+// step is one scripted response of a test server.
+type step struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+// script serves the steps for each path in order, repeating the last one,
+// and records the paths requested.
+type script struct {
+	mu       sync.Mutex
+	steps    map[string][]step
+	requests []string
+}
+
+func (s *script) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	steps := s.steps[r.URL.Path]
+	if len(steps) == 0 {
+		s.mu.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+	st := steps[0]
+	if len(steps) > 1 {
+		s.steps[r.URL.Path] = steps[1:]
+	}
+	s.mu.Unlock()
+
+	for k, v := range st.header {
+		w.Header().Set(k, strings.ReplaceAll(v, "{server}", "http://"+r.Host))
+	}
+	w.WriteHeader(st.status)
+	w.Write([]byte(st.body))
+}
+
+func testClient(t *testing.T, s *script) *Client {
+	t.Helper()
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return &Client{
+		Endpoint:        srv.URL,
+		HTTPClient:      srv.Client(),
+		PollInterval:    time.Millisecond,
+		MaxPollInterval: 4 * time.Millisecond,
+		PollTimeout:     5 * time.Second,
+	}
+}
+
+func TestPutArtifactPolling(t *testing.T) {
+	const put = "/notebooks/nb"
+	accepted := func(header map[string]string) []step {
+		return []step{{status: http.StatusAccepted, header: header}}
+	}
+
+	tests := []struct {
+		name     string
+		steps    map[string][]step
+		wantErr  string
+		wantReqs []string
+	}{
+		{
+			name:     "created synchronously",
+			steps:    map[string][]step{put: {{status: http.StatusOK, body: `{"name":"nb"}`}}},
+			wantReqs: []string{"PUT " + put},
+		},
+		{
+			name: "location until it stops answering 202",
+			steps: map[string][]step{
+				put:     accepted(map[string]string{"Location": "{server}/op/1"}),
+				"/op/1": {{status: http.StatusAccepted}, {status: http.StatusAccepted}, {status: http.StatusOK, body: `{"name":"nb"}`}},
+			},
+			wantReqs: []string{"PUT " + put, "GET /op/1", "GET /op/1", "GET /op/1"},
+		},
+		{
+			name: "location result reporting failure",
+			steps: map[string][]step{
+				put:     accepted(map[string]string{"Location": "{server}/op/1"}),
+				"/op/1": {{status: http.StatusOK, body: `{"status":"Failed","error":{"code":"BadRequest","message":"invalid cell"}}`}},
+			},
+			wantErr: "Failed: BadRequest: invalid cell",
+		},
+		{
+			name: "azure-asyncoperation preferred over location",
+			steps: map[string][]step{
+				put:         accepted(map[string]string{"Azure-AsyncOperation": "{server}/status/1", "Location": "{server}/op/1"}),
+				"/status/1": {{status: http.StatusOK, body: `{"status":"InProgress"}`}, {status: http.StatusOK, body: `{"status":"Succeeded"}`}},
+			},
+			wantReqs: []string{"PUT " + put, "GET /status/1", "GET /status/1"},
+		},
+		{
+			name: "status monitor failed",
+			steps: map[string][]step{
+				put:         accepted(map[string]string{"Azure-AsyncOperation": "{server}/status/1"}),
+				"/status/1": {{status: http.StatusOK, body: `{"status":"Failed","error":{"code":"Conflict","message":"pool is busy"}}`}},
+			},
+			wantErr: "Failed: Conflict: pool is busy",
+		},
+		{
+			name: "status monitor canceled",
+			steps: map[string][]step{
+				put:         accepted(map[string]string{"Azure-AsyncOperation": "{server}/status/1"}),
+				"/status/1": {{status: http.StatusOK, body: `{"status":"Canceled"}`}},
+			},
+			wantErr: "/status/1: Canceled",
+		},
+		{
+			name: "status monitor answering garbage",
+			steps: map[string][]step{
+				put:         accepted(map[string]string{"Azure-AsyncOperation": "{server}/status/1"}),
+				"/status/1": {{status: http.StatusOK, body: `<html>`}},
+			},
+			wantErr: "failed to parse operation status",
+		},
+		{
+			name: "monitor failing",
+			steps: map[string][]step{
+				put:     accepted(map[string]string{"Location": "{server}/op/1"}),
+				"/op/1": {{status: http.StatusForbidden, body: "denied"}},
+			},
+			wantErr: "returned 403: denied",
+		},
+		{
+			name:     "202 without a header to follow",
+			steps:    map[string][]step{put: accepted(nil)},
+			wantErr:  "was accepted without a Location or Azure-AsyncOperation header",
+			wantReqs: []string{"PUT " + put},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &script{steps: tt.steps}
+			c := testClient(t, s)
+
+			err := c.PutArtifact(context.Background(), artifact.Notebook, "nb", []byte(`{"name":"nb"}`))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("PutArtifact error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("PutArtifact: %v", err)
+			}
+			if tt.wantReqs != nil && strings.Join(s.requests, ", ") != strings.Join(tt.wantReqs, ", ") {
+				t.Errorf("requests = %v, want %v", s.requests, tt.wantReqs)
+			}
+		})
+	}
+}
+
+func TestOperationError(t *testing.T) {
+	s := &script{steps: map[string][]step{
+		"/notebooks/nb": {{status: http.StatusAccepted, header: map[string]string{"Azure-AsyncOperation": "{server}/status/1"}}},
+		"/status/1":     {{status: http.StatusOK, body: `{"status":"Failed"}`}},
+	}}
+	err := testClient(t, s).DeleteArtifact(context.Background(), artifact.Notebook, "nb")
+	var opErr *OperationError
+	if !errors.As(err, &opErr) || opErr.Status != "Failed" {
+		t.Fatalf("DeleteArtifact error = %v, want an *OperationError", err)
+	}
+}
+
+func TestPollTimeout(t *testing.T) {
+	s := &script{steps: map[string][]step{
+		"/notebooks/nb": {{status: http.StatusAccepted, header: map[string]string{"Location": "{server}/op/1"}}},
+		"/op/1":         {{status: http.StatusAccepted}},
+	}}
+	c := testClient(t, s)
+	c.PollTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	err := c.PutArtifact(context.Background(), artifact.Notebook, "nb", []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "gave up waiting for operation") {
+		t.Fatalf("PutArtifact error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("gave up after %v, want about %v", elapsed, c.PollTimeout)
+	}
+	if len(s.requests) < 3 {
+		t.Errorf("polled %d times before giving up", len(s.requests)-1)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	interval := time.Second
+	var got []time.Duration
+	for i := 0; i < 7; i++ {
+		interval = backoff(interval, 30*time.Second)
+		got = append(got, interval)
+	}
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second, 30 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("backoff sequence = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", time.Second},
+		{"5", 5 * time.Second},
+		{"0", 0},
+		{"-1", time.Second},
+		{"soon", time.Second},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.value != "" {
+			header.Set("Retry-After", tt.value)
+		}
+		if got := retryAfter(header, time.Second); got != tt.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+
+	"github.com/utsavudhungana/artifactsrepo/httpclient"
+)
+
+// Scope is the OAuth 2.0 scope of the Synapse data plane in the public cloud.
+const Scope = "https://dev.azuresynapse.net/.default"
+
+// Auth modes select how Credentials obtain tokens.
+const (
+	// AuthAuto uses the service principal secret when the credentials are
+	// complete and DefaultAzureCredential otherwise.
+	AuthAuto            = ""
+	AuthClientSecret    = "client-secret"
+	AuthDefault         = "default"
+	AuthManagedIdentity = "managed-identity"
+	AuthAzureCLI        = "azure-cli"
+)
+
+// CheckAuth returns an error for an unknown auth mode.
+func CheckAuth(mode string) error {
+	switch mode {
+	case AuthAuto, AuthClientSecret, AuthDefault, AuthManagedIdentity, AuthAzureCLI:
+		return nil
+	default:
+		return fmt.Errorf("unknown auth mode %q: use %s, %s, %s or %s",
+			mode, AuthClientSecret, AuthDefault, AuthManagedIdentity, AuthAzureCLI)
+	}
+}
+
+// Credentials identifies the identity used to call Synapse. With AuthAuto,
+// DefaultAzureCredential is used when any service principal field is empty.
+type Credentials struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+	// Auth is one of the auth modes.
+	Auth string
+	// Cloud is the Azure cloud; the zero value is the public cloud.
+	Cloud Cloud
+}
+
+// CredentialsFromEnv reads the service principal from AZURE_TENANT_ID,
+// AZURE_CLIENT_ID and AZURE_CLIENT_SECRET.
+func CredentialsFromEnv() Credentials {
+	return Credentials{
+		TenantID:     os.Getenv("AZURE_TENANT_ID"),
+		ClientID:     os.Getenv("AZURE_CLIENT_ID"),
+		ClientSecret: os.Getenv("AZURE_CLIENT_SECRET"),
+	}
+}
+
+// complete reports whether the service principal fields are all set.
+func (c Credentials) complete() bool {
+	return c.TenantID != "" && c.ClientID != "" && c.ClientSecret != ""
+}
+
+// Token obtains a Synapse access token for the credentials.
+func (c Credentials) Token(ctx context.Context) (string, error) {
+	if c.Auth == AuthClientSecret || (c.Auth == AuthAuto && c.complete()) {
+		if !c.complete() {
+			return "", fmt.Errorf("%s auth needs a tenant ID, client ID and AZURE_CLIENT_SECRET", AuthClientSecret)
+		}
+		return accessToken(ctx, c.Cloud.orPublic(), c.TenantID, c.ClientID, c.ClientSecret)
+	}
+
+	cred, err := c.TokenCredential()
+	if err != nil {
+		return "", err
+	}
+
+	token, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{c.Cloud.Scope()}})
+	if err != nil {
+		return "", fmt.Errorf("failed to get access token: %v", err)
+	}
+	return token.Token, nil
+}
+
+// TokenCredential returns an Azure SDK credential for the auth mode.
+func (c Credentials) TokenCredential() (azcore.TokenCredential, error) {
+	clientOptions := ClientOptions()
+	clientOptions.Cloud = c.Cloud.configuration()
+
+	switch c.Auth {
+	case AuthAuto, AuthClientSecret:
+		if c.complete() {
+			cred, err := azidentity.NewClientSecretCredential(c.TenantID, c.ClientID, c.ClientSecret,
+				&azidentity.ClientSecretCredentialOptions{ClientOptions: clientOptions})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create client secret credential: %v", err)
+			}
+			return cred, nil
+		}
+		if c.Auth == AuthClientSecret {
+			return nil, fmt.Errorf("%s auth needs a tenant ID, client ID and AZURE_CLIENT_SECRET", AuthClientSecret)
+		}
+	case AuthManagedIdentity:
+		opts := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions}
+		if c.ClientID != "" {
+			opts.ID = azidentity.ClientID(c.ClientID)
+		}
+		cred, err := azidentity.NewManagedIdentityCredential(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create managed identity credential: %v", err)
+		}
+		return cred, nil
+	case AuthAzureCLI:
+		cred, err := azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{TenantID: c.TenantID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure CLI credential: %v", err)
+		}
+		return cred, nil
+	case AuthDefault:
+	default:
+		return nil, CheckAuth(c.Auth)
+	}
+
+	cred, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+		ClientOptions: clientOptions,
+		TenantID:      c.TenantID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default Azure credentials: %v", err)
+	}
+	return cred, nil
+}
+
+// ClientOptions routes Azure SDK clients through the shared HTTP client,
+// whose retry policy replaces the SDK's own.
+func ClientOptions() azcore.ClientOptions {
+	return azcore.ClientOptions{
+		Transport: httpclient.Default(),
+		Retry:     policy.RetryOptions{MaxRetries: -1},
+	}
+}
+
+// GetAccessToken obtains an access token from Azure AD with the client
+// credentials flow.
+func GetAccessToken(ctx context.Context, tenantID, clientID, clientSecret string) (string, error) {
+	return accessToken(ctx, PublicCloud, tenantID, clientID, clientSecret)
+}
+
+func accessToken(ctx context.Context, cloud Cloud, tenantID, clientID, clientSecret string) (string, error) {
+	tokenURL := fmt.Sprintf("%s%s/oauth2/v2.0/token", cloud.Authority, tenantID)
+
+	data := url.Values{}
+	data.Set("client_id", clientID)
+	data.Set("client_secret", clientSecret)
+	data.Set("scope", cloud.Scope())
+	data.Set("grant_type", "client_credentials")
+
+	// Requesting a token has no side effects, so the POST may be retried
+	ctx = httpclient.WithIdempotent(ctx)
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, bytes.NewBufferString(data.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpclient.Default().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get token: %v", string(body))
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("error parsing JSON response: %v", err)
+	}
+
+	if token, ok := result["access_token"].(string); ok {
+		return token, nil
+	}
+
+	return "", fmt.Errorf("no access token found in response")
+}
+
+// GetDefaultAccessToken obtains an access token through DefaultAzureCredential,
+// which covers managed identity, workload identity and az CLI logins.
+func GetDefaultAccessToken(ctx context.Context) (string, error) {
+	return Credentials{Auth: AuthDefault}.Token(ctx)
+}
@@ -0,0 +1,46 @@
+package synapse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/utsavudhungana/artifactsrepo/httpclient"
+)
+
+func TestAccessTokenRetriesServerErrors(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/tenant/oauth2/v2.0/token" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("client_secret") != "secret" {
+			t.Errorf("attempt %d lost its form: %v", attempts.Load()+1, r.PostForm)
+		}
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"access_token":"token"}`))
+	}))
+	defer srv.Close()
+
+	previous := httpclient.Default()
+	defer httpclient.SetDefault(previous)
+	opts := httpclient.DefaultOptions()
+	opts.MinBackoff, opts.MaxBackoff = time.Millisecond, time.Millisecond
+	httpclient.SetDefault(httpclient.New(opts))
+
+	cloud := PublicCloud
+	cloud.Authority = srv.URL + "/"
+	token, err := accessToken(context.Background(), cloud, "tenant", "client", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token" || attempts.Load() != 2 {
+		t.Errorf("got %q after %d attempts, want the token after 2", token, attempts.Load())
+	}
+}
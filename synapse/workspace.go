@@ -0,0 +1,66 @@
+package synapse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+	"github.com/utsavudhungana/artifactsrepo/httpclient"
+)
+
+// WorkspaceAPIVersion is the Azure Resource Manager API version used to look
+// up workspaces.
+const WorkspaceAPIVersion = "2021-06-01"
+
+// WorkspaceEndpoint looks the named workspace up in Azure Resource Manager
+// and returns its development endpoint. A workspace that is not in the given
+// subscription and resource group is an error, so a run cannot reach a
+// workspace of another environment. The identity needs read access to the
+// workspace resource.
+func (c Credentials) WorkspaceEndpoint(ctx context.Context, subscription, resourceGroup, name string) (string, error) {
+	cred, err := c.TokenCredential()
+	if err != nil {
+		return "", err
+	}
+	token, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{c.Cloud.ResourceManagerScope()}})
+	if err != nil {
+		return "", fmt.Errorf("failed to get Resource Manager access token: %v", err)
+	}
+
+	client := &Client{Token: token.Token, HTTPClient: httpclient.Default()}
+	return client.workspaceEndpoint(ctx, "https://"+c.Cloud.orPublic().ResourceManager, subscription, resourceGroup, name)
+}
+
+// workspaceEndpoint fetches the workspace resource below the Resource
+// Manager URL armURL.
+func (c *Client) workspaceEndpoint(ctx context.Context, armURL, subscription, resourceGroup, name string) (string, error) {
+	apiURL := fmt.Sprintf("%s/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Synapse/workspaces/%s?api-version=%s",
+		armURL, url.PathEscape(subscription), url.PathEscape(resourceGroup), url.PathEscape(name), WorkspaceAPIVersion)
+
+	body, err := c.do(ctx, http.MethodGet, apiURL, nil)
+	if IsNotFound(err) {
+		return "", fmt.Errorf("workspace %s not found in resource group %s of subscription %s", name, resourceGroup, subscription)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up workspace %s: %v", name, err)
+	}
+
+	var ws struct {
+		Properties struct {
+			ConnectivityEndpoints map[string]string `json:"connectivityEndpoints"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(body, &ws); err != nil {
+		return "", fmt.Errorf("failed to parse workspace %s: %v", name, err)
+	}
+	endpoint := ws.Properties.ConnectivityEndpoints["dev"]
+	if endpoint == "" {
+		return "", fmt.Errorf("workspace %s has no development endpoint", name)
+	}
+	return strings.TrimSuffix(endpoint, "/"), nil
+}
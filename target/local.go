@@ -0,0 +1,135 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+	"github.com/utsavudhungana/artifactsrepo/packager"
+)
+
+// Directory writes artifacts below Root in the Synapse Git layout, so a
+// deploy run can be reviewed without touching a workspace.
+type Directory struct {
+	Root string
+}
+
+// Publish implements ArtifactTarget.
+func (d *Directory) Publish(ctx context.Context, a artifact.Artifact) error {
+	dest := filepath.Join(d.Root, filepath.FromSlash(a.Path()))
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", a.Key(), err)
+	}
+	if err := os.WriteFile(dest, a.Content, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", dest, err)
+	}
+	return nil
+}
+
+// Get implements Reader.
+func (d *Directory) Get(ctx context.Context, artifactType, name string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(d.Root, artifactType, name+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return content, err
+}
+
+// List implements Reader.
+func (d *Directory) List(ctx context.Context, artifactType string) ([]artifact.Artifact, error) {
+	paths, err := filepath.Glob(filepath.Join(d.Root, artifactType, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var artifacts []artifact.Artifact
+	for _, p := range paths {
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %v", p, err)
+		}
+
+		a, err := artifact.FromDefinition(artifactType, content, p)
+		if err != nil {
+			return nil, err
+		}
+		if a.Name == "" {
+			a.Name = artifact.NameFromPath(p)
+		}
+		artifacts = append(artifacts, a)
+	}
+	return artifacts, nil
+}
+
+// Delete implements Deleter.
+func (d *Directory) Delete(ctx context.Context, artifactType, name string) error {
+	err := os.Remove(filepath.Join(d.Root, artifactType, name+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s/%s", ErrNotFound, artifactType, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete %s/%s: %v", artifactType, name, err)
+	}
+	return nil
+}
+
+// Close implements ArtifactTarget.
+func (d *Directory) Close() error {
+	return nil
+}
+
+func (d *Directory) String() string {
+	return d.Root
+}
+
+// Zip collects artifacts and writes them to a zip package at Path on Close.
+type Zip struct {
+	Path string
+	// Source is recorded in the package manifest.
+	Source manifest.Source
+
+	mu        sync.Mutex
+	artifacts []artifact.Artifact
+	aborted   bool
+}
+
+// Publish implements ArtifactTarget.
+func (z *Zip) Publish(ctx context.Context, a artifact.Artifact) error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.artifacts = append(z.artifacts, a)
+	return nil
+}
+
+// Abort implements Aborter. Close writes nothing afterwards.
+func (z *Zip) Abort() {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.artifacts = nil
+	z.aborted = true
+}
+
+// Close implements ArtifactTarget.
+func (z *Zip) Close() error {
+	if z.aborted {
+		return nil
+	}
+	data, err := packager.Build(z.artifacts, z.Source)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(z.Path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write package: %v", err)
+	}
+	return nil
+}
+
+func (z *Zip) String() string {
+	return z.Path
+}
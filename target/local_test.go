@@ -0,0 +1,135 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/archive"
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/manifest"
+)
+
+var (
+	notebook = artifact.Artifact{Type: artifact.Notebook, Name: "nb1", Content: []byte(`{"name":"nb1"}`)}
+	pipeline = artifact.Artifact{Type: artifact.Pipeline, Name: "p1", Content: []byte(`{"name":"p1","properties":{"folder":{"name":"etl"}}}`)}
+)
+
+func TestDirectory(t *testing.T) {
+	ctx := context.Background()
+	d := OpenLocal(filepath.Join(t.TempDir(), "out")).(*Directory)
+
+	for _, a := range []artifact.Artifact{notebook, pipeline} {
+		if err := d.Publish(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Abort(d); err != nil {
+		t.Fatal(err)
+	}
+
+	// A directory has no buffer to discard, so what was written stays
+	content, err := d.Get(ctx, artifact.Notebook, "nb1")
+	if err != nil || string(content) != string(notebook.Content) {
+		t.Errorf("Get(nb1) = %s, %v", content, err)
+	}
+	listed, err := d.List(ctx, artifact.Pipeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keys(listed); got != "pipeline/p1" || listed[0].Folder != "etl" {
+		t.Errorf("List = %s in %q, want pipeline/p1 in etl", got, listed[0].Folder)
+	}
+
+	if err := d.Delete(ctx, artifact.Notebook, "nb1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Get(ctx, artifact.Notebook, "nb1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+	if err := d.Delete(ctx, artifact.Notebook, "nb1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestZip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "review.zip")
+	z := OpenLocal(path).(*Zip)
+
+	for _, a := range []artifact.Artifact{pipeline, notebook} {
+		if err := z.Publish(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := archive.Unzip(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifacts, _, err := artifact.FromFiles(files, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := manifest.Parse(files[manifest.FileName])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Verify(artifacts); err != nil {
+		t.Errorf("manifest does not match the package: %v", err)
+	}
+	if got := keys(artifacts); got != "notebook/nb1, pipeline/p1" {
+		t.Errorf("package holds %s", got)
+	}
+}
+
+func TestZipAbort(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		existing bool
+	}{
+		{name: "new file"},
+		{name: "existing file", existing: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".zip")
+			if tt.existing {
+				if err := os.WriteFile(path, []byte("previous review"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			z := OpenLocal(path)
+			if err := z.Publish(ctx, notebook); err != nil {
+				t.Fatal(err)
+			}
+			if err := Abort(z); err != nil {
+				t.Fatal(err)
+			}
+			if err := z.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(path)
+			switch {
+			case tt.existing && string(data) != "previous review":
+				t.Errorf("aborted run changed %s: %q, %v", path, data, err)
+			case !tt.existing && !errors.Is(err, os.ErrNotExist):
+				t.Errorf("aborted run wrote %s", path)
+			}
+		})
+	}
+}
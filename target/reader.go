@@ -0,0 +1,30 @@
+package target
+
+import (
+	"context"
+	"errors"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+)
+
+// ErrNotFound is returned, possibly wrapped, for an artifact the target
+// does not hold, and by List for a type the workspace does not serve.
+var ErrNotFound = errors.New("artifact not found")
+
+// Reader is implemented by targets that can read back the artifacts they
+// hold, which plan mode needs to compare a package with a workspace.
+type Reader interface {
+	// Get returns the definition of an artifact, or ErrNotFound.
+	Get(ctx context.Context, artifactType, name string) ([]byte, error)
+	// List returns every artifact of a type, or ErrNotFound when the
+	// target does not serve the type at all.
+	List(ctx context.Context, artifactType string) ([]artifact.Artifact, error)
+}
+
+// Deleter is implemented by targets that can remove artifacts, which prune
+// mode needs.
+type Deleter interface {
+	// Delete removes an artifact, or fails with ErrNotFound when there is
+	// none.
+	Delete(ctx context.Context, artifactType, name string) error
+}
@@ -0,0 +1,69 @@
+package target
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/synapse"
+)
+
+// REST publishes artifacts with plain calls to the Synapse data-plane API.
+type REST struct {
+	Client *synapse.Client
+}
+
+// Publish implements ArtifactTarget.
+func (r *REST) Publish(ctx context.Context, a artifact.Artifact) error {
+	return r.Client.PutArtifact(ctx, a.Type, a.Name, a.Content)
+}
+
+// Get implements Reader.
+func (r *REST) Get(ctx context.Context, artifactType, name string) ([]byte, error) {
+	content, err := r.Client.GetArtifact(ctx, artifactType, name)
+	if synapse.IsNotFound(err) {
+		return nil, ErrNotFound
+	}
+	return content, err
+}
+
+// List implements Reader.
+func (r *REST) List(ctx context.Context, artifactType string) ([]artifact.Artifact, error) {
+	items, err := r.Client.ListArtifacts(ctx, artifactType)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	artifacts := make([]artifact.Artifact, 0, len(items))
+	for _, item := range items {
+		a, err := artifact.FromDefinition(artifactType, item, r.Client.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, a)
+	}
+	return artifacts, nil
+}
+
+// Delete implements Deleter.
+func (r *REST) Delete(ctx context.Context, artifactType, name string) error {
+	return notFound(r.Client.DeleteArtifact(ctx, artifactType, name))
+}
+
+// Close implements ArtifactTarget.
+func (r *REST) Close() error {
+	return nil
+}
+
+func (r *REST) String() string {
+	return r.Client.Endpoint
+}
+
+// notFound wraps a Synapse 404 in ErrNotFound, so callers need not know
+// which client answered.
+func notFound(err error) error {
+	if synapse.IsNotFound(err) {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return err
+}
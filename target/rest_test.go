@@ -0,0 +1,175 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/synapse"
+)
+
+// reply is a canned response of a workspace.
+type reply struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+// workspace answers requests by method and path, with query strings
+// ignored, and records each request with its body.
+type workspace struct {
+	mu       sync.Mutex
+	replies  map[string]reply
+	requests []string
+	bodies   []string
+}
+
+func (w *workspace) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	key := r.Method + " " + r.URL.Path
+	if r.URL.Query().Get("page") != "" {
+		key += "?page=" + r.URL.Query().Get("page")
+	}
+
+	w.mu.Lock()
+	w.requests = append(w.requests, key)
+	w.bodies = append(w.bodies, string(body))
+	rep, ok := w.replies[key]
+	w.mu.Unlock()
+
+	if !ok {
+		rw.WriteHeader(http.StatusNotFound)
+		rw.Write([]byte(`{"error":{"code":"NotFound","message":"not found"}}`))
+		return
+	}
+	for k, v := range rep.header {
+		rw.Header().Set(k, strings.ReplaceAll(v, "{server}", "https://"+r.Host))
+	}
+	if rep.body != "" {
+		rw.Header().Set("Content-Type", "application/json")
+	}
+	rw.WriteHeader(rep.status)
+	rw.Write([]byte(strings.ReplaceAll(rep.body, "{server}", "https://"+r.Host)))
+}
+
+func (w *workspace) requested() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return strings.Join(w.requests, ", ")
+}
+
+// testREST serves w over TLS, the way the SDK tests do.
+func testREST(t *testing.T, w *workspace) *REST {
+	t.Helper()
+	srv := httptest.NewTLSServer(w)
+	t.Cleanup(srv.Close)
+
+	client := synapse.NewClient("ws", "token")
+	client.Endpoint = srv.URL
+	client.HTTPClient = srv.Client()
+	client.PollInterval = time.Millisecond
+	client.MaxPollInterval = time.Millisecond
+	return &REST{Client: client}
+}
+
+// listReplies serves two pages of notebooks, linked by nextLink.
+var listReplies = map[string]reply{
+	"GET /notebooks":        {status: http.StatusOK, body: `{"value":[{"name":"nb1"},{"name":"nb2","properties":{"folder":{"name":"etl"}}}],"nextLink":"{server}/notebooks?api-version=2020-12-01&page=2"}`},
+	"GET /notebooks?page=2": {status: http.StatusOK, body: `{"value":[{"name":"nb3"}]}`},
+}
+
+func keys(artifacts []artifact.Artifact) string {
+	var keys []string
+	for _, a := range artifacts {
+		keys = append(keys, a.Key())
+	}
+	return strings.Join(keys, ", ")
+}
+
+func TestRESTPublish(t *testing.T) {
+	w := &workspace{replies: map[string]reply{
+		"PUT /notebooks/nb1": {status: http.StatusAccepted, header: map[string]string{"Location": "{server}/operations/1"}},
+		"GET /operations/1":  {status: http.StatusOK, body: `{"name":"nb1"}`},
+	}}
+	r := testREST(t, w)
+
+	a := artifact.Artifact{Type: artifact.Notebook, Name: "nb1", Content: []byte(`{"name":"nb1"}`)}
+	if err := r.Publish(context.Background(), a); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.requested(), "PUT /notebooks/nb1, GET /operations/1"; got != want {
+		t.Errorf("requests = %s, want %s", got, want)
+	}
+	if w.bodies[0] != `{"name":"nb1"}` {
+		t.Errorf("PUT body = %s", w.bodies[0])
+	}
+}
+
+func TestRESTList(t *testing.T) {
+	r := testREST(t, &workspace{replies: listReplies})
+
+	artifacts, err := r.List(context.Background(), artifact.Notebook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := keys(artifacts), "notebook/nb1, notebook/nb2, notebook/nb3"; got != want {
+		t.Errorf("List = %s, want %s", got, want)
+	}
+	if artifacts[1].Folder != "etl" {
+		t.Errorf("folder of nb2 = %q, want etl", artifacts[1].Folder)
+	}
+}
+
+func TestRESTGet(t *testing.T) {
+	r := testREST(t, &workspace{replies: map[string]reply{
+		"GET /notebooks/nb1": {status: http.StatusOK, body: `{"name":"nb1"}`},
+	}})
+
+	content, err := r.Get(context.Background(), artifact.Notebook, "nb1")
+	if err != nil || string(content) != `{"name":"nb1"}` {
+		t.Errorf("Get(nb1) = %s, %v", content, err)
+	}
+	if _, err := r.Get(context.Background(), artifact.Notebook, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRESTDelete(t *testing.T) {
+	w := &workspace{replies: map[string]reply{
+		"DELETE /pipelines/p1": {status: http.StatusOK},
+	}}
+	r := testREST(t, w)
+
+	if err := r.Delete(context.Background(), artifact.Pipeline, "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.requested(), "DELETE /pipelines/p1"; got != want {
+		t.Errorf("requests = %s, want %s", got, want)
+	}
+	if err := r.Delete(context.Background(), artifact.Pipeline, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRESTNotFound(t *testing.T) {
+	r := testREST(t, &workspace{replies: map[string]reply{
+		"GET /notebooks": {status: http.StatusForbidden, body: `{"error":{"code":"Forbidden"}}`},
+	}})
+
+	// A workspace without the type, such as Spark configurations on an
+	// older API version, answers 404
+	if _, err := r.List(context.Background(), artifact.SparkConfiguration); !errors.Is(err, ErrNotFound) {
+		t.Errorf("List(sparkConfiguration) error = %v, want ErrNotFound", err)
+	}
+	_, err := r.List(context.Background(), artifact.Notebook)
+	if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "403") {
+		t.Errorf("List(notebook) error = %v, want the 403", err)
+	}
+}
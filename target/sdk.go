@@ -0,0 +1,206 @@
+package target
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/synapse"
+	"github.com/utsavudhungana/artifactsrepo/version"
+)
+
+// SDK publishes artifacts through an Azure SDK for Go pipeline rather than
+// the synapse client REST uses. The pipeline's bearer token policy caches
+// and refreshes tokens from an azcore.TokenCredential, and the SDK's pollers
+// and pagers follow long-running operations and nextLink pages, so a run
+// longer than a token's lifetime keeps working. The requests themselves are
+// the data-plane calls REST makes.
+type SDK struct {
+	// Endpoint is the workspace development endpoint.
+	Endpoint string
+	Pipeline runtime.Pipeline
+	// PollFrequency is the delay between polls of a long-running operation
+	// when Synapse does not send Retry-After.
+	PollFrequency time.Duration
+}
+
+// NewSDK creates an SDK target for a workspace development endpoint in
+// cloud. A nil options routes requests through the shared HTTP client.
+func NewSDK(endpoint string, cloud synapse.Cloud, cred azcore.TokenCredential, options *azcore.ClientOptions) *SDK {
+	if options == nil {
+		defaults := synapse.ClientOptions()
+		options = &defaults
+	}
+	pipeline := runtime.NewPipeline("synapsectl", version.Version, runtime.PipelineOptions{
+		PerRetry: []policy.Policy{runtime.NewBearerTokenPolicy(cred, []string{cloud.Scope()}, nil)},
+	}, options)
+
+	return &SDK{Endpoint: endpoint, Pipeline: pipeline, PollFrequency: 5 * time.Second}
+}
+
+// Publish implements ArtifactTarget.
+func (s *SDK) Publish(ctx context.Context, a artifact.Artifact) error {
+	if artifact.IsReadOnly(a.Type) {
+		return fmt.Errorf("%s artifacts cannot be written through the data plane", a.Type)
+	}
+	apiURL, err := synapse.ArtifactURL(s.Endpoint, a.Type, a.Name)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.send(ctx, http.MethodPut, apiURL, a.Content, http.StatusOK, http.StatusCreated, http.StatusAccepted)
+	if err != nil {
+		return err
+	}
+	return s.wait(ctx, resp)
+}
+
+// Get implements Reader.
+func (s *SDK) Get(ctx context.Context, artifactType, name string) ([]byte, error) {
+	apiURL, err := synapse.ArtifactURL(s.Endpoint, artifactType, name)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.send(ctx, http.MethodGet, apiURL, nil, http.StatusOK)
+	if isNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return runtime.Payload(resp)
+}
+
+// List implements Reader.
+func (s *SDK) List(ctx context.Context, artifactType string) ([]artifact.Artifact, error) {
+	first, err := synapse.ArtifactURL(s.Endpoint, artifactType, "")
+	if err != nil {
+		return nil, err
+	}
+
+	type page struct {
+		Value    []json.RawMessage `json:"value"`
+		NextLink string            `json:"nextLink"`
+	}
+	pager := runtime.NewPager(runtime.PagingHandler[page]{
+		More: func(p page) bool {
+			return p.NextLink != ""
+		},
+		Fetcher: func(ctx context.Context, prev *page) (page, error) {
+			apiURL := first
+			if prev != nil {
+				apiURL = prev.NextLink
+			}
+			var p page
+			resp, err := s.send(ctx, http.MethodGet, apiURL, nil, http.StatusOK)
+			if isNotFound(err) {
+				return p, fmt.Errorf("%w: %v", ErrNotFound, err)
+			}
+			if err != nil {
+				return p, err
+			}
+			if err := runtime.UnmarshalAsJSON(resp, &p); err != nil {
+				return p, fmt.Errorf("failed to parse %s list: %v", artifactType, err)
+			}
+			return p, nil
+		},
+	})
+
+	var artifacts []artifact.Artifact
+	for pager.More() {
+		p, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range p.Value {
+			a, err := artifact.FromDefinition(artifactType, item, s.Endpoint)
+			if err != nil {
+				return nil, err
+			}
+			artifacts = append(artifacts, a)
+		}
+	}
+	return artifacts, nil
+}
+
+// Delete implements Deleter.
+func (s *SDK) Delete(ctx context.Context, artifactType, name string) error {
+	if artifact.IsReadOnly(artifactType) {
+		return fmt.Errorf("%s artifacts cannot be deleted through the data plane", artifactType)
+	}
+	apiURL, err := synapse.ArtifactURL(s.Endpoint, artifactType, name)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.send(ctx, http.MethodDelete, apiURL, nil, http.StatusOK, http.StatusAccepted, http.StatusNoContent)
+	if isNotFound(err) {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+	return s.wait(ctx, resp)
+}
+
+// Close implements ArtifactTarget.
+func (s *SDK) Close() error {
+	return nil
+}
+
+func (s *SDK) String() string {
+	return s.Endpoint
+}
+
+// send issues a request through the pipeline and fails with an
+// *azcore.ResponseError unless Synapse answers with one of statuses.
+func (s *SDK) send(ctx context.Context, method, apiURL string, content []byte, statuses ...int) (*http.Response, error) {
+	req, err := runtime.NewRequest(ctx, method, apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %v", method, err)
+	}
+	if content != nil {
+		if err := req.SetBody(streaming.NopCloser(bytes.NewReader(content)), "application/json"); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := s.Pipeline.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if !runtime.HasStatusCode(resp, statuses...) {
+		return nil, runtime.NewResponseError(resp)
+	}
+	return resp, nil
+}
+
+// wait follows the long-running operation resp starts, if any, until it
+// finishes.
+func (s *SDK) wait(ctx context.Context, resp *http.Response) error {
+	poller, err := runtime.NewPoller[json.RawMessage](resp, s.Pipeline, nil)
+	if err != nil {
+		return fmt.Errorf("%s %s: %v", resp.Request.Method, resp.Request.URL, err)
+	}
+	if _, err := poller.PollUntilDone(ctx, &runtime.PollUntilDoneOptions{Frequency: s.PollFrequency}); err != nil {
+		return fmt.Errorf("%s %s: %v", resp.Request.Method, resp.Request.URL, err)
+	}
+	return nil
+}
+
+// isNotFound reports whether err is a 404 response to a pipeline request.
+func isNotFound(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,191 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"github.com/utsavudhungana/artifactsrepo/synapse"
+)
+
+// staticToken is an azcore.TokenCredential counting the tokens it issues.
+type staticToken struct {
+	mu     sync.Mutex
+	issued int
+	scopes []string
+}
+
+func (c *staticToken) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.issued++
+	c.scopes = opts.Scopes
+	return azcore.AccessToken{Token: "sdk-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// authorized records the Authorization header of every request.
+type authorized struct {
+	http.Handler
+	mu      sync.Mutex
+	headers []string
+}
+
+func (a *authorized) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	a.headers = append(a.headers, r.Header.Get("Authorization"))
+	a.mu.Unlock()
+	a.Handler.ServeHTTP(w, r)
+}
+
+func testSDK(t *testing.T, w *workspace) (*SDK, *staticToken, *authorized) {
+	t.Helper()
+	auth := &authorized{Handler: w}
+	srv := httptest.NewTLSServer(auth)
+	t.Cleanup(srv.Close)
+
+	cred := &staticToken{}
+	s := NewSDK(srv.URL, synapse.PublicCloud, cred, &azcore.ClientOptions{
+		Transport: srv.Client(),
+		Retry:     policy.RetryOptions{MaxRetries: -1},
+	})
+	s.PollFrequency = time.Millisecond
+	return s, cred, auth
+}
+
+func TestSDKPublish(t *testing.T) {
+	tests := []struct {
+		name     string
+		replies  map[string]reply
+		wantErr  string
+		wantReqs string
+	}{
+		{
+			name:     "created synchronously",
+			replies:  map[string]reply{"PUT /notebooks/nb1": {status: http.StatusOK, body: `{"name":"nb1"}`}},
+			wantReqs: "PUT /notebooks/nb1",
+		},
+		{
+			name: "location",
+			replies: map[string]reply{
+				"PUT /notebooks/nb1": {status: http.StatusAccepted, header: map[string]string{"Location": "{server}/operations/1"}},
+				"GET /operations/1":  {status: http.StatusOK, body: `{"name":"nb1"}`},
+			},
+			wantReqs: "PUT /notebooks/nb1, GET /operations/1",
+		},
+		{
+			name: "azure-asyncoperation failed",
+			replies: map[string]reply{
+				"PUT /notebooks/nb1": {status: http.StatusAccepted, header: map[string]string{"Azure-AsyncOperation": "{server}/status/1"}},
+				"GET /status/1":      {status: http.StatusOK, body: `{"status":"Failed","error":{"code":"BadRequest","message":"invalid cell"}}`},
+			},
+			wantErr: "invalid cell",
+		},
+		{
+			name:    "rejected",
+			replies: map[string]reply{"PUT /notebooks/nb1": {status: http.StatusBadRequest, body: `{"error":{"code":"BadRequest","message":"bad notebook"}}`}},
+			wantErr: "bad notebook",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &workspace{replies: tt.replies}
+			s, cred, auth := testSDK(t, w)
+
+			a := artifact.Artifact{Type: artifact.Notebook, Name: "nb1", Content: []byte(`{"name":"nb1"}`)}
+			err := s.Publish(context.Background(), a)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Publish error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := w.requested(); got != tt.wantReqs {
+				t.Errorf("requests = %s, want %s", got, tt.wantReqs)
+			}
+			if w.bodies[0] != `{"name":"nb1"}` {
+				t.Errorf("PUT body = %s", w.bodies[0])
+			}
+			for _, h := range auth.headers {
+				if h != "Bearer sdk-token" {
+					t.Errorf("Authorization = %q, want the pipeline's token", h)
+				}
+			}
+			if cred.issued != 1 || cred.scopes[0] != synapse.PublicCloud.Scope() {
+				t.Errorf("issued %d tokens for %v, want 1 cached token for the data plane", cred.issued, cred.scopes)
+			}
+		})
+	}
+}
+
+func TestSDKPublishReadOnly(t *testing.T) {
+	w := &workspace{}
+	s, _, _ := testSDK(t, w)
+
+	a := artifact.Artifact{Type: artifact.IntegrationRuntime, Name: "AutoResolveIntegrationRuntime", Content: []byte(`{}`)}
+	if err := s.Publish(context.Background(), a); err == nil {
+		t.Fatal("published an integration runtime")
+	}
+	if w.requested() != "" {
+		t.Errorf("requests = %s, want none", w.requested())
+	}
+}
+
+func TestSDKList(t *testing.T) {
+	s, _, _ := testSDK(t, &workspace{replies: listReplies})
+
+	artifacts, err := s.List(context.Background(), artifact.Notebook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := keys(artifacts), "notebook/nb1, notebook/nb2, notebook/nb3"; got != want {
+		t.Errorf("List = %s, want %s", got, want)
+	}
+	if _, err := s.List(context.Background(), artifact.SparkConfiguration); !errors.Is(err, ErrNotFound) {
+		t.Errorf("List(sparkConfiguration) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSDKGet(t *testing.T) {
+	s, _, _ := testSDK(t, &workspace{replies: map[string]reply{
+		"GET /notebooks/nb1": {status: http.StatusOK, body: `{"name":"nb1"}`},
+	}})
+
+	content, err := s.Get(context.Background(), artifact.Notebook, "nb1")
+	if err != nil || string(content) != `{"name":"nb1"}` {
+		t.Errorf("Get(nb1) = %s, %v", content, err)
+	}
+	if _, err := s.Get(context.Background(), artifact.Notebook, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSDKDelete(t *testing.T) {
+	w := &workspace{replies: map[string]reply{
+		"DELETE /pipelines/p1": {status: http.StatusAccepted, header: map[string]string{"Location": "{server}/operations/2"}},
+		"GET /operations/2":    {status: http.StatusNoContent},
+	}}
+	s, _, _ := testSDK(t, w)
+
+	if err := s.Delete(context.Background(), artifact.Pipeline, "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.requested(), "DELETE /pipelines/p1, GET /operations/2"; got != want {
+		t.Errorf("requests = %s, want %s", got, want)
+	}
+	if err := s.Delete(context.Background(), artifact.Pipeline, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete(missing) error = %v, want ErrNotFound", err)
+	}
+}
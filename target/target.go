@@ -0,0 +1,48 @@
+// Package target writes Synapse artifacts to a destination: a workspace via
+// the data-plane REST API or the Azure SDK pipeline, or a local directory or
+// zip file for dry runs.
+package target
+
+import (
+	"context"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+)
+
+// ArtifactTarget receives the artifacts of a deploy run.
+type ArtifactTarget interface {
+	// Publish creates or updates a single artifact. It may be called
+	// concurrently for artifacts that do not depend on each other.
+	Publish(ctx context.Context, a artifact.Artifact) error
+	// Close flushes anything the target buffered. It is called once after
+	// the last Publish.
+	Close() error
+	// String describes the target for log lines.
+	String() string
+}
+
+// Aborter is implemented by targets that buffer artifacts until Close.
+// Abort discards them, so a failed run does not write a partial result.
+type Aborter interface {
+	Abort()
+}
+
+// Abort ends a failed run: it discards what an Aborter buffered, and closes
+// any other target.
+func Abort(t ArtifactTarget) error {
+	if a, ok := t.(Aborter); ok {
+		a.Abort()
+		return nil
+	}
+	return t.Close()
+}
+
+// OpenLocal returns the dry-run target for a path: a zip file when the path
+// ends in .zip, a directory otherwise.
+func OpenLocal(path string) ArtifactTarget {
+	if strings.HasSuffix(path, ".zip") {
+		return &Zip{Path: path}
+	}
+	return &Directory{Root: path}
+}
@@ -0,0 +1,172 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var errPathNotFound = errors.New("path not found")
+
+// splitPath splits a JSON path into its segments. Segments are separated by
+// dots; [n] indexes an array and ["key"] names a key holding dots or
+// brackets, so a[0].b["c.d"] becomes a, 0, b, c.d.
+func splitPath(p string) ([]string, error) {
+	var segments []string
+	for i := 0; i < len(p); {
+		switch {
+		case p[i] == '[' && i+1 < len(p) && (p[i+1] == '"' || p[i+1] == '\''):
+			quote := p[i+1]
+			end := strings.IndexByte(p[i+2:], quote)
+			if end < 0 || i+2+end+1 >= len(p) || p[i+2+end+1] != ']' {
+				return nil, fmt.Errorf("unterminated key at %q", p[i:])
+			}
+			segments = append(segments, p[i+2:i+2+end])
+			i += 2 + end + 2
+		case p[i] == '[':
+			end := strings.IndexByte(p[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index at %q", p[i:])
+			}
+			if _, err := strconv.Atoi(p[i+1 : i+end]); err != nil {
+				return nil, fmt.Errorf("invalid index at %q", p[i:])
+			}
+			segments = append(segments, p[i+1:i+end])
+			i += end + 1
+		default:
+			end := strings.IndexAny(p[i:], ".[")
+			if end < 0 {
+				end = len(p) - i
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty segment in path")
+			}
+			segments = append(segments, p[i:i+end])
+			i += end
+		}
+
+		if i < len(p) && p[i] == '.' {
+			i++
+			if i == len(p) {
+				return nil, fmt.Errorf("empty segment in path")
+			}
+		} else if i < len(p) && p[i] != '[' {
+			return nil, fmt.Errorf("unexpected %q in path", p[i:])
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	return segments, nil
+}
+
+// joinPath is the inverse of splitPath.
+func joinPath(segments []string) (string, error) {
+	var b strings.Builder
+	for i, s := range segments {
+		if !strings.ContainsAny(s, ".[]'\"") && s != "" {
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(s)
+			continue
+		}
+
+		quote := `"`
+		if strings.Contains(s, quote) {
+			quote = "'"
+		}
+		if strings.Contains(s, quote) {
+			return "", fmt.Errorf("key %q cannot be addressed by a path", s)
+		}
+		b.WriteString("[" + quote + s + quote + "]")
+	}
+	return b.String(), nil
+}
+
+// setPath replaces the value at path in content with value. Only the bytes
+// of the old value change, so the rest of the document keeps its layout.
+func setPath(content []byte, path []string, value any) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	start, end, err := locate(dec, content, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode value: %v", err)
+	}
+	encoded := bytes.TrimRight(buf.Bytes(), "\n")
+
+	out := make([]byte, 0, len(content)-(end-start)+len(encoded))
+	out = append(out, content[:start]...)
+	out = append(out, encoded...)
+	out = append(out, content[end:]...)
+	return out, nil
+}
+
+// locate returns the byte range of the value at path. dec must be positioned
+// before the value path is relative to.
+func locate(dec *json.Decoder, content []byte, path []string) (int, int, error) {
+	if len(path) == 0 {
+		start := int(dec.InputOffset())
+		for start < len(content) && strings.IndexByte(" \t\r\n:,", content[start]) >= 0 {
+			start++
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return 0, 0, fmt.Errorf("invalid JSON: %v", err)
+		}
+		return start, int(dec.InputOffset()), nil
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return 0, 0, fmt.Errorf("invalid JSON: %v", err)
+			}
+			if key == path[0] {
+				return locate(dec, content, path[1:])
+			}
+			if err := skip(dec); err != nil {
+				return 0, 0, err
+			}
+		}
+	case json.Delim('['):
+		index, err := strconv.Atoi(path[0])
+		if err != nil {
+			return 0, 0, errPathNotFound
+		}
+		for i := 0; dec.More(); i++ {
+			if i == index {
+				return locate(dec, content, path[1:])
+			}
+			if err := skip(dec); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+	return 0, 0, errPathNotFound
+}
+
+func skip(dec *json.Decoder) error {
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil && err != io.EOF {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	return nil
+}
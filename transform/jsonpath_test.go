@@ -0,0 +1,174 @@
+package transform
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr string
+	}{
+		{in: "properties", want: []string{"properties"}},
+		{in: "properties.typeProperties.url", want: []string{"properties", "typeProperties", "url"}},
+		{in: "activities[0].name", want: []string{"activities", "0", "name"}},
+		{in: "activities.0.name", want: []string{"activities", "0", "name"}},
+		{in: "a[1][2]", want: []string{"a", "1", "2"}},
+		{in: `conf["spark.executor.memory"]`, want: []string{"conf", "spark.executor.memory"}},
+		{in: `conf['spark.executor.memory'].x`, want: []string{"conf", "spark.executor.memory", "x"}},
+		{in: `a["it's"]`, want: []string{"a", "it's"}},
+		{in: `a['say "hi"']`, want: []string{"a", `say "hi"`}},
+		{in: `a["[0]"]`, want: []string{"a", "[0]"}},
+		{in: `["a.b"]`, want: []string{"a.b"}},
+		{in: "", wantErr: "empty path"},
+		{in: "a..b", wantErr: "empty segment in path"},
+		{in: ".a", wantErr: "empty segment in path"},
+		{in: "a.", wantErr: "empty segment in path"},
+		{in: "a[0", wantErr: "unterminated index"},
+		{in: "a[x]", wantErr: "invalid index"},
+		{in: `a["b`, wantErr: "unterminated key"},
+		{in: `a["b"`, wantErr: "unterminated key"},
+		{in: `a["b"]c`, wantErr: "unexpected"},
+	}
+
+	for _, tt := range tests {
+		got, err := splitPath(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("splitPath(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("splitPath(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	tests := []struct {
+		in      []string
+		want    string
+		wantErr bool
+	}{
+		{in: []string{"properties", "url"}, want: "properties.url"},
+		{in: []string{"activities", "0", "name"}, want: "activities.0.name"},
+		{in: []string{"conf", "spark.executor.memory"}, want: `conf["spark.executor.memory"]`},
+		{in: []string{"a", `say "hi"`}, want: `a['say "hi"']`},
+		{in: []string{"a", ""}, want: `a[""]`},
+		{in: []string{"a", `it's "x"`}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := joinPath(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("joinPath(%q) = %q, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("joinPath(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("joinPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if back, err := splitPath(got); err != nil || !reflect.DeepEqual(back, tt.in) {
+			t.Errorf("splitPath(%q) = %q, %v, want %q", got, back, err, tt.in)
+		}
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	const doc = `{
+  "name": "nb",
+  "properties": {
+    "conf": {"spark.executor.memory": "4g", "other": 1},
+    "cells": [
+      {"source": ["a"]},
+      {"source": ["b", "c"]}
+    ]
+  }
+}`
+
+	tests := []struct {
+		name    string
+		path    string
+		value   any
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "top level",
+			path:  "name",
+			value: "renamed",
+			want:  strings.Replace(doc, `"name": "nb"`, `"name": "renamed"`, 1),
+		},
+		{
+			name:  "key with dots",
+			path:  `properties.conf["spark.executor.memory"]`,
+			value: "8g",
+			want:  strings.Replace(doc, `"4g"`, `"8g"`, 1),
+		},
+		{
+			name:  "array index",
+			path:  "properties.cells[1].source[0]",
+			value: "B",
+			want:  strings.Replace(doc, `["b", "c"]`, `["B", "c"]`, 1),
+		},
+		{
+			name:  "numeric segment",
+			path:  "properties.cells.0.source",
+			value: []any{"x", "y"},
+			want:  strings.Replace(doc, `["a"]`, `["x","y"]`, 1),
+		},
+		{
+			name:  "object value",
+			path:  "properties.conf.other",
+			value: map[string]any{"a": "<b>"},
+			want:  strings.Replace(doc, `"other": 1`, `"other": {"a":"<b>"}`, 1),
+		},
+		{name: "missing key", path: "properties.absent", value: 1, wantErr: errPathNotFound},
+		{name: "index out of range", path: "properties.cells[2]", value: 1, wantErr: errPathNotFound},
+		{name: "key into an array", path: "properties.cells.source", value: 1, wantErr: errPathNotFound},
+		{name: "below a scalar", path: "name.first", value: 1, wantErr: errPathNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := splitPath(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := setPath([]byte(doc), path, tt.value)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("setPath(%s) error = %v, want %v", tt.path, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setPath(%s): %v", tt.path, err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("setPath(%s) =\n%s\nwant\n%s", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetPathInvalidJSON(t *testing.T) {
+	_, err := setPath([]byte(`{"a": [1, }`), []string{"b"}, 1)
+	if err == nil || errors.Is(err, errPathNotFound) {
+		t.Fatalf("setPath on invalid JSON error = %v, want a parse error", err)
+	}
+}
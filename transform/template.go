@@ -0,0 +1,334 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"gopkg.in/yaml.v3"
+)
+
+// resourceTypes maps the lower-cased last segment of the resource types in a
+// template parameter definition to artifact types. Resource types without
+// an artifact type, such as bigDataPools, are ignored.
+var resourceTypes = map[string]string{
+	"managedvirtualnetworks": artifact.ManagedVirtualNetwork,
+	"integrationruntimes":    artifact.IntegrationRuntime,
+	"credentials":            artifact.Credential,
+	"linkedservices":         artifact.LinkedService,
+	"datasets":               artifact.Dataset,
+	"dataflows":              artifact.DataFlow,
+	"notebooks":              artifact.Notebook,
+	"sqlscripts":             artifact.SQLScript,
+	"kqlscripts":             artifact.KQLScript,
+	"sparkconfigurations":    artifact.SparkConfiguration,
+	"sparkjobdefinitions":    artifact.SparkJobDefinition,
+	"pipelines":              artifact.Pipeline,
+	"triggers":               artifact.Trigger,
+}
+
+// typedResources are the artifact types whose definitions are keyed first by
+// the artifact's properties.type, or "*" for every one of them.
+var typedResources = map[string]bool{
+	artifact.LinkedService: true,
+	artifact.Dataset:       true,
+}
+
+// Definition is a template-parameters-definition.json file as used by the
+// Synapse Studio and Data Factory publish flow, keyed by artifact type.
+type Definition map[string]any
+
+// LoadDefinition loads a template parameter definition file.
+func LoadDefinition(filePath string) (Definition, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parameter definition: %v", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse parameter definition %s: %v", filePath, err)
+	}
+
+	def := make(Definition)
+	for resourceType, tree := range raw {
+		segment := resourceType[strings.LastIndex(resourceType, "/")+1:]
+		if t, ok := resourceTypes[strings.ToLower(segment)]; ok {
+			def[t] = tree
+		}
+	}
+	return def, nil
+}
+
+// Parameter is a property of an artifact selected by a definition.
+type Parameter struct {
+	// Name is the name the ARM template flow gives the parameter, such as
+	// AzureDataLakeStorage1_properties_typeProperties_url.
+	Name string
+	// Type is the ARM parameter type: string, int, bool, object, array or
+	// secureString.
+	Type string
+	// Target addresses the property in a parameter file rule.
+	Target string
+	// Value is the current value of the property.
+	Value any
+	// KeepDefault reports whether the current value is the default ("=").
+	KeepDefault bool
+	// Secure reports a secret ("|" or a secure type) that must be supplied.
+	Secure bool
+}
+
+// Parameters returns the properties of artifacts selected by the definition,
+// in artifact order and then by target.
+func (d Definition) Parameters(artifacts []artifact.Artifact) ([]Parameter, error) {
+	var params []Parameter
+	for _, a := range artifacts {
+		tree, ok := d[a.Type]
+		if !ok {
+			continue
+		}
+
+		var doc any
+		if err := json.Unmarshal(a.Content, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", a.Key(), err)
+		}
+
+		trees := []any{tree}
+		if typedResources[a.Type] {
+			trees = typedTrees(tree, doc)
+		}
+
+		found := make(map[string]Parameter)
+		for _, t := range trees {
+			err := walkDefinition(t, doc, nil, func(p []string, spec string, value any) error {
+				param, err := newParameter(a, p, spec, value)
+				if err != nil {
+					return err
+				}
+				found[param.Target] = param
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", a.Key(), err)
+			}
+		}
+
+		targets := make([]string, 0, len(found))
+		for target := range found {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+		for _, target := range targets {
+			params = append(params, found[target])
+		}
+	}
+	return params, nil
+}
+
+// typedTrees returns the "*" definition followed by the one for the
+// artifact's own properties.type, so the more specific one wins.
+func typedTrees(tree, doc any) []any {
+	byType, ok := tree.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var trees []any
+	if t, ok := byType["*"]; ok {
+		trees = append(trees, t)
+	}
+	obj, _ := doc.(map[string]any)
+	if props, ok := obj["properties"].(map[string]any); ok {
+		if kind, ok := props["type"].(string); ok {
+			if t, ok := byType[kind]; ok {
+				trees = append(trees, t)
+			}
+		}
+	}
+	return trees
+}
+
+// walkDefinition calls fn for every string leaf of def that exists in doc.
+// A "*" key matches every key of the object, and an array definition applies
+// its first item to every item of the array.
+func walkDefinition(def, doc any, p []string, fn func(p []string, spec string, value any) error) error {
+	switch def := def.(type) {
+	case string:
+		return fn(p, def, doc)
+	case map[string]any:
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for _, key := range sortedKeys(def) {
+			if key == "*" {
+				for _, k := range sortedKeys(obj) {
+					if err := walkDefinition(def[key], obj[k], appendPath(p, k), fn); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if v, ok := obj[key]; ok {
+				if err := walkDefinition(def[key], v, appendPath(p, key), fn); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		items, ok := doc.([]any)
+		if !ok || len(def) == 0 {
+			return nil
+		}
+		for i, item := range items {
+			if err := walkDefinition(def[0], item, appendPath(p, strconv.Itoa(i)), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func appendPath(p []string, segment string) []string {
+	return append(append([]string(nil), p...), segment)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// newParameter creates the parameter for the property at p of a. spec is
+// the definition value, <action>[:<name>[:<type>]], where action is "="
+// (keep the current value as default), "-" (no default) or "|" (secret),
+// and a name starting with "-" drops the property path from the ARM name.
+func newParameter(a artifact.Artifact, p []string, spec string, value any) (Parameter, error) {
+	jsonPath, err := joinPath(p)
+	if err != nil {
+		return Parameter{}, err
+	}
+
+	parts := strings.SplitN(spec, ":", 3)
+	action, name, kind := parts[0], "", "string"
+	if len(parts) > 1 {
+		name = parts[1]
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		kind = parts[2]
+	}
+	if action != "=" && action != "-" && action != "|" {
+		return Parameter{}, fmt.Errorf("invalid parameter definition %q for %s", spec, strings.Join(p, "."))
+	}
+
+	switch {
+	case name == "":
+		name = strings.Join(p, "_")
+	case strings.HasPrefix(name, "-"):
+		name = name[1:]
+	default:
+		name = strings.Join(append(p[:len(p)-1:len(p)-1], name), "_")
+	}
+
+	return Parameter{
+		Name:        a.Name + "_" + name,
+		Type:        kind,
+		Target:      a.Type + "/" + globEscaper.Replace(a.Name) + ":" + jsonPath,
+		Value:       value,
+		KeepDefault: action == "=",
+		Secure:      action == "|" || strings.HasPrefix(strings.ToLower(kind), "secure"),
+	}, nil
+}
+
+// globEscaper escapes the pattern characters of an artifact name so a rule
+// targets only that artifact.
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`)
+
+// LoadARMParameters loads the values of an ARM template parameter file,
+// keyed by parameter name. Key Vault references are left out.
+func LoadARMParameters(filePath string) (map[string]any, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ARM parameter file: %v", err)
+	}
+
+	var file struct {
+		Parameters map[string]struct {
+			Value *any `json:"value"`
+		} `json:"parameters"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse ARM parameter file %s: %v", filePath, err)
+	}
+
+	values := make(map[string]any)
+	for name, p := range file.Parameters {
+		if p.Value != nil {
+			values[name] = *p.Value
+		}
+	}
+	return values, nil
+}
+
+// Skeleton renders params as a parameter file for LoadParameters. A value in
+// values, keyed by parameter name, replaces the current value; secrets and
+// parameters without a default are left null, which LoadParameters rejects
+// until they are filled in. Each rule is commented with its ARM parameter
+// name and type.
+func Skeleton(params []Parameter, values map[string]any) ([]byte, error) {
+	rules := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, p := range params {
+		value, ok := values[p.Name]
+		comment := fmt.Sprintf("%s (%s)", p.Name, p.Type)
+		if !ok {
+			switch {
+			case p.Secure:
+				value = nil
+				comment += ", secret: set a value"
+			case !p.KeepDefault:
+				value = nil
+				comment += ", required: set a value"
+			default:
+				value = p.Value
+			}
+		}
+
+		var valueNode yaml.Node
+		if err := valueNode.Encode(value); err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %v", p.Name, err)
+		}
+		rules.Content = append(rules.Content, &yaml.Node{
+			Kind:        yaml.MappingNode,
+			HeadComment: comment,
+			Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Value: "target"},
+				{Kind: yaml.ScalarNode, Value: p.Target},
+				{Kind: yaml.ScalarNode, Value: "value"},
+				&valueNode,
+			},
+		})
+	}
+
+	doc := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "parameters"},
+		rules,
+	}}
+
+	var buf strings.Builder
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to render parameter file: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to render parameter file: %v", err)
+	}
+	return []byte(buf.String()), nil
+}
@@ -0,0 +1,226 @@
+package transform
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+)
+
+// loadWorkspace reads the artifacts below testdata/workspace.
+func loadWorkspace(t *testing.T) []artifact.Artifact {
+	t.Helper()
+	root := filepath.Join("testdata", "workspace")
+	var artifacts []artifact.Artifact
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		a, err := artifact.New(filepath.ToSlash(rel), content)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, a)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact.Sort(artifacts)
+	return artifacts
+}
+
+func TestLoadDefinition(t *testing.T) {
+	def, err := LoadDefinition(filepath.Join("testdata", "template-parameters-definition.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for t := range def {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	if want := []string{artifact.LinkedService, artifact.Notebook, artifact.Pipeline, artifact.Trigger}; !reflect.DeepEqual(types, want) {
+		t.Errorf("definition types = %v, want %v without bigDataPools", types, want)
+	}
+
+	if _, err := LoadDefinition(filepath.Join("testdata", "missing.json")); err == nil {
+		t.Error("LoadDefinition of a missing file succeeded")
+	}
+}
+
+func TestParameters(t *testing.T) {
+	def, err := LoadDefinition(filepath.Join("testdata", "template-parameters-definition.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := def.Parameters(loadWorkspace(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Parameter{
+		{
+			// "|" with a "-" name replaces the property path
+			Name:   "ls_lake_storageKey",
+			Type:   "secureString",
+			Target: "linkedService/ls_lake:properties.typeProperties.accountKey",
+			Value:  map[string]any{"type": "SecureString", "value": "**********"},
+			Secure: true,
+		},
+		{
+			// "=" from the "*" section, for every linked service type
+			Name:        "ls_lake_properties_typeProperties_url",
+			Type:        "string",
+			Target:      "linkedService/ls_lake:properties.typeProperties.url",
+			Value:       "https://devlake.dfs.core.windows.net",
+			KeepDefault: true,
+		},
+		{
+			// a plain name only replaces the last segment
+			Name:   "ls_sql_properties_typeProperties_connectionString",
+			Type:   "secureString",
+			Target: "linkedService/ls_sql:properties.typeProperties.connectionString",
+			Value:  "Server=tcp:dev.database.windows.net;Database=sales",
+			Secure: true,
+		},
+		{
+			// the AzureSqlDatabase section overrides "*"
+			Name:   "ls_sql_properties_typeProperties_url",
+			Type:   "string",
+			Target: "linkedService/ls_sql:properties.typeProperties.url",
+			Value:  "https://dev.database.windows.net",
+		},
+		{
+			Name:        "nb_load_properties_bigDataPool_referenceName",
+			Type:        "string",
+			Target:      "notebook/nb_load:properties.bigDataPool.referenceName",
+			Value:       "devpool",
+			KeepDefault: true,
+		},
+		{
+			// an array definition applies to every item that has the
+			// property
+			Name:   "pl_daily_properties_activities_0_typeProperties_waitTimeInSeconds",
+			Type:   "int",
+			Target: "pipeline/pl_daily:properties.activities.0.typeProperties.waitTimeInSeconds",
+			Value:  float64(30),
+		},
+		{
+			// a "*" key selects every property of the object
+			Name:        "tr_hourly_properties_typeProperties_recurrence_frequency",
+			Type:        "string",
+			Target:      "trigger/tr_hourly:properties.typeProperties.recurrence.frequency",
+			Value:       "Hour",
+			KeepDefault: true,
+		},
+		{
+			Name:        "tr_hourly_properties_typeProperties_recurrence_interval",
+			Type:        "string",
+			Target:      "trigger/tr_hourly:properties.typeProperties.recurrence.interval",
+			Value:       float64(1),
+			KeepDefault: true,
+		},
+	}
+	if len(params) != len(want) {
+		t.Fatalf("Parameters returned %d parameters, want %d: %+v", len(params), len(want), params)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(params[i], want[i]) {
+			t.Errorf("parameter %d = %+v\nwant %+v", i, params[i], want[i])
+		}
+	}
+}
+
+func TestParametersInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     Definition
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unknown action",
+			def:     Definition{artifact.Notebook: map[string]any{"properties": map[string]any{"folder": "~"}}},
+			content: `{"name":"nb","properties":{"folder":"x"}}`,
+			wantErr: `notebook/nb: invalid parameter definition "~" for properties.folder`,
+		},
+		{
+			name:    "invalid artifact",
+			def:     Definition{artifact.Notebook: map[string]any{"properties": "="}},
+			content: `{"name":"nb",`,
+			wantErr: "failed to parse notebook/nb",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := artifact.Artifact{Type: artifact.Notebook, Name: "nb", Content: []byte(tt.content)}
+			_, err := tt.def.Parameters([]artifact.Artifact{a})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parameters error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParametersEscapeNames(t *testing.T) {
+	def := Definition{artifact.Notebook: map[string]any{"properties": map[string]any{"folder": "="}}}
+	a := artifact.Artifact{Type: artifact.Notebook, Name: "nb[*]?", Content: []byte(`{"properties":{"folder":"x"}}`)}
+	params, err := def.Parameters([]artifact.Artifact{a})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 1 || params[0].Target != `notebook/nb\[\*]\?:properties.folder` {
+		t.Fatalf("Parameters = %+v, want a target matching only nb[*]?", params)
+	}
+	rule, err := NewRule(params[0].Target, "y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rule.matches(a) || rule.matches(artifact.Artifact{Type: artifact.Notebook, Name: "nb1?"}) {
+		t.Errorf("rule %s does not target exactly %s", params[0].Target, a.Name)
+	}
+}
+
+func TestSkeleton(t *testing.T) {
+	def, err := LoadDefinition(filepath.Join("testdata", "template-parameters-definition.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := def.Parameters(loadWorkspace(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := LoadARMParameters(filepath.Join("testdata", "arm-parameters.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := values["ls_lake_storageKey"]; ok {
+		t.Error("LoadARMParameters kept a Key Vault reference")
+	}
+
+	got, err := Skeleton(params, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "skeleton.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("Skeleton =\n%s\nwant\n%s", got, want)
+	}
+}
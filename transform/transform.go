@@ -0,0 +1,189 @@
+// Package transform rewrites artifact definitions before they are deployed.
+package transform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule sets the value at a JSON path in the artifacts it targets. Target is
+// written type/name:path, for example
+// linkedService/*:properties.typeProperties.url. The type and name are
+// globs; the path is dot separated, with [n] or a numeric segment indexing
+// an array.
+type Rule struct {
+	Target string `yaml:"target"`
+	Value  any    `yaml:"value"`
+
+	artifactType string
+	name         string
+	path         []string
+}
+
+// Parameters is an ordered list of rules.
+type Parameters struct {
+	Rules []Rule `yaml:"parameters"`
+}
+
+// LoadParameters loads the rules of a YAML (or JSON) parameter file shaped
+// like:
+//
+//	parameters:
+//	  - target: linkedService/*:properties.typeProperties.url
+//	    value: https://prodstorage.dfs.core.windows.net/
+//	  - target: sparkJobDefinition/*:properties.jobProperties.numExecutors
+//	    value: 4
+func LoadParameters(filePath string) (*Parameters, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parameter file: %v", err)
+	}
+
+	var params Parameters
+	if err := yaml.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse parameter file %s: %v", filePath, err)
+	}
+	for i := range params.Rules {
+		if err := params.Rules[i].parse(); err != nil {
+			return nil, fmt.Errorf("%s: %v", filePath, err)
+		}
+	}
+	return &params, nil
+}
+
+// NewRule creates a rule setting value at target.
+func NewRule(target string, value any) (Rule, error) {
+	r := Rule{Target: target, Value: value}
+	return r, r.parse()
+}
+
+func (r *Rule) parse() error {
+	key, jsonPath, ok := strings.Cut(r.Target, ":")
+	if !ok || jsonPath == "" {
+		return fmt.Errorf("parameter target %q is not type/name:path", r.Target)
+	}
+	// A null or missing value is a rule left unfilled, such as one of a
+	// generated skeleton, rather than a request to clear the property
+	if r.Value == nil {
+		return fmt.Errorf("parameter %s has no value", r.Target)
+	}
+	artifactType, name, ok := strings.Cut(key, "/")
+	if !ok || artifactType == "" || name == "" {
+		return fmt.Errorf("parameter target %q is not type/name:path", r.Target)
+	}
+	for _, pattern := range []string{artifactType, name} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("parameter target %q: invalid pattern %q", r.Target, pattern)
+		}
+	}
+	if !strings.ContainsAny(artifactType, "*?[") {
+		if _, err := artifact.TypeFromFolder(artifactType); err != nil {
+			return fmt.Errorf("parameter target %q: unknown artifact type %q", r.Target, artifactType)
+		}
+	}
+
+	segments, err := splitPath(jsonPath)
+	if err != nil {
+		return fmt.Errorf("parameter target %q: %v", r.Target, err)
+	}
+
+	r.artifactType = artifactType
+	r.name = name
+	r.path = segments
+	return nil
+}
+
+// Expand replaces every string in the rule values, at any depth, with the
+// result of expand. It resolves placeholders such as ${env:NAME} before the
+// rules are applied.
+func (p *Parameters) Expand(ctx context.Context, expand func(ctx context.Context, s string) (string, error)) error {
+	for i := range p.Rules {
+		value, err := expandValue(ctx, p.Rules[i].Value, expand)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %v", p.Rules[i].Target, err)
+		}
+		p.Rules[i].Value = value
+	}
+	return nil
+}
+
+func expandValue(ctx context.Context, value any, expand func(ctx context.Context, s string) (string, error)) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return expand(ctx, v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			expanded, err := expandValue(ctx, item, expand)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = expanded
+		}
+		return out, nil
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			expanded, err := expandValue(ctx, item, expand)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = expanded
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+// matches reports whether the rule targets a.
+func (r *Rule) matches(a artifact.Artifact) bool {
+	typeMatch, _ := path.Match(r.artifactType, a.Type)
+	nameMatch, _ := path.Match(r.name, a.Name)
+	return typeMatch && nameMatch
+}
+
+// Apply applies the rules in order to the artifacts. An artifact matched by
+// type and name but lacking the path is left alone; a rule that sets nothing
+// in any artifact is an error, as is a null value or one that cannot be
+// encoded as JSON.
+func (p *Parameters) Apply(artifacts []artifact.Artifact) error {
+	var errs []error
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.path == nil || r.Value == nil {
+			if err := r.parse(); err != nil {
+				return err
+			}
+		}
+
+		matched := 0
+		for j := range artifacts {
+			if !r.matches(artifacts[j]) {
+				continue
+			}
+
+			content, err := setPath(artifacts[j].Content, r.path, r.Value)
+			if errors.Is(err, errPathNotFound) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to apply parameter %s to %s: %v", r.Target, artifacts[j].Key(), err)
+			}
+			artifacts[j].Content = content
+			matched++
+		}
+
+		if matched == 0 {
+			errs = append(errs, fmt.Errorf("parameter %s matched no artifact", r.Target))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,161 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/utsavudhungana/artifactsrepo/artifact"
+)
+
+func TestNewRule(t *testing.T) {
+	tests := []struct {
+		target  string
+		value   any
+		wantErr string
+	}{
+		{target: "linkedService/*:properties.typeProperties.url", value: "https://x"},
+		{target: "*/ls_?:properties.url", value: 1},
+		{target: `notebook/nb:properties.conf["spark.x"]`, value: "1"},
+		{target: "linkedService/ls", value: 1, wantErr: "is not type/name:path"},
+		{target: "linkedService/ls:", value: 1, wantErr: "is not type/name:path"},
+		{target: "linkedService:properties.url", value: 1, wantErr: "is not type/name:path"},
+		{target: "/ls:properties.url", value: 1, wantErr: "is not type/name:path"},
+		{target: "linkedService/ls:properties.url", value: nil, wantErr: "has no value"},
+		{target: "linkedServices/ls:properties.url", value: 1, wantErr: `unknown artifact type "linkedServices"`},
+		{target: "linkedService/[ls:properties.url", value: 1, wantErr: `invalid pattern "[ls"`},
+		{target: "linkedService/ls:properties..url", value: 1, wantErr: "empty segment in path"},
+	}
+
+	for _, tt := range tests {
+		_, err := NewRule(tt.target, tt.value)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("NewRule(%q): %v", tt.target, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("NewRule(%q) error = %v, want %q", tt.target, err, tt.wantErr)
+		}
+	}
+}
+
+func testArtifacts(t *testing.T) []artifact.Artifact {
+	t.Helper()
+	var artifacts []artifact.Artifact
+	for p, def := range map[string]string{
+		"linkedService/ls_a.json": `{"name":"ls_a","properties":{"typeProperties":{"url":"https://dev-a"}}}`,
+		"linkedService/ls_b.json": `{"name":"ls_b","properties":{"typeProperties":{"url":"https://dev-b"}}}`,
+		"linkedService/ls_c.json": `{"name":"ls_c","properties":{"typeProperties":{"server":"dev"}}}`,
+		"notebook/nb.json":        `{"name":"nb","properties":{"conf":{"spark.x":"1"}}}`,
+	} {
+		a, err := artifact.New(p, []byte(def))
+		if err != nil {
+			t.Fatal(err)
+		}
+		artifacts = append(artifacts, a)
+	}
+	artifact.Sort(artifacts)
+	return artifacts
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   [][2]any
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:  "glob skips artifacts without the path",
+			rules: [][2]any{{"linkedService/*:properties.typeProperties.url", "https://prod"}},
+			want: map[string]string{
+				"linkedService/ls_a": `{"name":"ls_a","properties":{"typeProperties":{"url":"https://prod"}}}`,
+				"linkedService/ls_b": `{"name":"ls_b","properties":{"typeProperties":{"url":"https://prod"}}}`,
+				"linkedService/ls_c": `{"name":"ls_c","properties":{"typeProperties":{"server":"dev"}}}`,
+			},
+		},
+		{
+			name: "later rules win",
+			rules: [][2]any{
+				{"linkedService/*:properties.typeProperties.url", "https://prod"},
+				{"linkedService/ls_b:properties.typeProperties.url", "https://prod-b"},
+			},
+			want: map[string]string{
+				"linkedService/ls_a": `{"name":"ls_a","properties":{"typeProperties":{"url":"https://prod"}}}`,
+				"linkedService/ls_b": `{"name":"ls_b","properties":{"typeProperties":{"url":"https://prod-b"}}}`,
+			},
+		},
+		{
+			name:  "key with dots",
+			rules: [][2]any{{`notebook/nb:properties.conf["spark.x"]`, 2}},
+			want:  map[string]string{"notebook/nb": `{"name":"nb","properties":{"conf":{"spark.x":2}}}`},
+		},
+		{
+			name: "rule matching nothing",
+			rules: [][2]any{
+				{"notebook/nb:properties.absent", 1},
+				{"pipeline/*:properties.x", 1},
+			},
+			wantErr: "parameter notebook/nb:properties.absent matched no artifact\nparameter pipeline/*:properties.x matched no artifact",
+		},
+		{
+			name:    "value that cannot be encoded",
+			rules:   [][2]any{{"notebook/nb:properties.conf", map[string]any{"f": func() {}}}},
+			wantErr: "failed to apply parameter notebook/nb:properties.conf to notebook/nb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params Parameters
+			for _, r := range tt.rules {
+				rule, err := NewRule(r[0].(string), r[1])
+				if err != nil {
+					t.Fatal(err)
+				}
+				params.Rules = append(params.Rules, rule)
+			}
+
+			artifacts := testArtifacts(t)
+			err := params.Apply(artifacts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Apply error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, a := range artifacts {
+				if want, ok := tt.want[a.Key()]; ok && string(a.Content) != want {
+					t.Errorf("%s = %s, want %s", a.Key(), a.Content, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	params := Parameters{Rules: []Rule{
+		{Target: "linkedService/ls_a:properties.typeProperties.url", Value: "${url}"},
+		{Target: "notebook/nb:properties.conf", Value: map[string]any{"a": []any{"${x}", 1}}},
+	}}
+	expand := func(ctx context.Context, s string) (string, error) {
+		if s == "${x}" {
+			return "", fmt.Errorf("x is not set")
+		}
+		return strings.ReplaceAll(s, "${url}", "https://prod"), nil
+	}
+
+	err := params.Expand(context.Background(), expand)
+	if err == nil || err.Error() != "parameter notebook/nb:properties.conf: x is not set" {
+		t.Fatalf("Expand error = %v", err)
+	}
+	if params.Rules[0].Value != "https://prod" {
+		t.Errorf("expanded value = %v, want https://prod", params.Rules[0].Value)
+	}
+}
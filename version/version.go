@@ -0,0 +1,6 @@
+// Package version reports the synapsectl release.
+package version
+
+// Version is the synapsectl release, overridden at build time with
+// -ldflags "-X github.com/utsavudhungana/artifactsrepo/version.Version=v1.2.3".
+var Version = "v0.1.0"